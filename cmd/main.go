@@ -16,7 +16,6 @@ import (
 	"syscall"
 
 	"github.com/gin-gonic/gin"
-	"github.com/sony/gobreaker"
 )
 
 func main() {
@@ -45,73 +44,34 @@ func main() {
 		log.Printf("Warning: SQLite initialization failed: %v", err)
 	}
 
-	// 2. Ensure Consumer Group exists (only if Redis is available)
-	if database.RDB != nil {
-		err = database.RDB.XGroupCreateMkStream(context.Background(), "signals:trading", "trading-group", "$").Err()
-		if err != nil {
-			log.Printf("Note: Consumer group setup: %v (usually means it already exists)", err)
-		}
-	}
+	// 2. Select the signal queue: Redis Streams, or the embedded SQLite queue without Redis
+	processor.InitQueue(cfg)
 
 	// 3. Initialize Executors and Processor
-	okxRaw := exchange.NewOKXExecutor(cfg)
-	bybitRaw := exchange.NewBybitExecutor(cfg)
-	backpackRaw, err := exchange.NewBackpackExecutor(cfg)
-	if err != nil {
-		log.Printf("Warning: Backpack executor disabled: %v", err)
-	} else if backpackRaw == nil {
-		log.Println("Note: Backpack executor not configured (API keys can be set via admin panel)")
-	}
-	lighterRaw := exchange.NewLighterExecutor(cfg)
-
-	okxCB := gobreaker.NewCircuitBreaker(gobreaker.Settings{
-		Name:         "OKX",
-		IsSuccessful: exchange.IsSuccessful,
-	})
-	bybitCB := gobreaker.NewCircuitBreaker(gobreaker.Settings{
-		Name:         "Bybit",
-		IsSuccessful: exchange.IsSuccessful,
-	})
-	lighterCB := gobreaker.NewCircuitBreaker(gobreaker.Settings{
-		Name:         "Lighter",
-		IsSuccessful: exchange.IsSuccessful,
-	})
-
-	okxExecutor := exchange.NewResilientExecutor(okxRaw, okxCB)
-	bybitExecutor := exchange.NewResilientExecutor(bybitRaw, bybitCB)
-	lighterExecutor := exchange.NewResilientExecutor(lighterRaw, lighterCB)
-
-	// Backpack executor is optional
-	var backpackExecutor models.ExchangeExecutor
-	if backpackRaw != nil {
-		backpackCB := gobreaker.NewCircuitBreaker(gobreaker.Settings{
-			Name:         "Backpack",
-			IsSuccessful: exchange.IsSuccessful,
-		})
-		backpackExecutor = exchange.NewResilientExecutor(backpackRaw, backpackCB)
-	}
-
-	proc := processor.NewSignalProcessor(cfg, okxExecutor, bybitExecutor, backpackExecutor, lighterExecutor)
-
-	// 4. Start Binance Listener (Produces to Redis)
-	binanceListener := exchange.NewBinanceListener(cfg)
-	if err := binanceListener.Start(); err != nil {
-		log.Printf("Warning: Failed to start Binance listener: %v", err)
+	executors := exchange.BuildExecutors(cfg)
+	proc := processor.NewSignalProcessor(cfg, executors)
+
+	// 4. Start Listeners (Produce to the signal queue)
+	listeners := exchange.BuildListeners(cfg)
+	for id, listener := range listeners {
+		if err := listener.Start(); err != nil {
+			log.Printf("Warning: Failed to start %s listener for account %s: %v", listener.Name(), id, err)
+		}
+		if provider, ok := listener.(models.BalanceProvider); ok {
+			proc.RegisterSource(id, provider)
+		}
 	}
 
-	// 5. Start Signal Processor (Consumes from Redis)
+	// 5. Start Signal Processor (Consumes from the signal queue)
 	if err := proc.Start(); err != nil {
 		log.Printf("Warning: Failed to start processor: %v", err)
 	}
 
-	// 6. Start Reconciler
-	executors := []models.ExchangeExecutor{okxExecutor, bybitExecutor, lighterExecutor}
-	if backpackExecutor != nil {
-		executors = append(executors, backpackExecutor)
-	}
+	// 6. Start Reconciler and Outbox Relay
 	reconciler := processor.NewReconciler(executors)
 	ctx, cancel := context.WithCancel(context.Background())
 	go reconciler.Start(ctx)
+	go processor.RunOutboxRelay(ctx)
 
 	// 7. Initialize API
 	r := gin.Default()
@@ -136,7 +96,9 @@ func main() {
 
 	log.Println("Shutting down...")
 	cancel()
-	binanceListener.Stop()
+	for _, listener := range listeners {
+		listener.Stop()
+	}
 	proc.Stop()
 	log.Println("Shutdown complete")
 }
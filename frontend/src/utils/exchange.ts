@@ -3,6 +3,8 @@ export interface ExchangeInfo {
   name: string
   icon: string
   fields: ExchangeField[]
+  testnet?: boolean      // Accounts can trade on the venue's test network
+  settleInUSDC?: boolean // Only USDC perpetuals are listed, see settle_in_usdc
 }
 
 export interface ExchangeField {
@@ -20,7 +22,8 @@ export const EXCHANGES: ExchangeInfo[] = [
     fields: [
       { key: 'api_key', label: 'API Key', type: 'password', placeholder: '请输入 API Key' },
       { key: 'api_secret', label: 'Secret Key', type: 'password', placeholder: '请输入 Secret Key' }
-    ]
+    ],
+    testnet: true
   },
   {
     id: 'okx',
@@ -30,7 +33,8 @@ export const EXCHANGES: ExchangeInfo[] = [
       { key: 'api_key', label: 'API Key', type: 'password', placeholder: '请输入 API Key' },
       { key: 'api_secret', label: 'Secret Key', type: 'password', placeholder: '请输入 Secret Key' },
       { key: 'passphrase', label: 'Passphrase', type: 'password', placeholder: '请输入 Passphrase' }
-    ]
+    ],
+    testnet: true
   },
   {
     id: 'bybit',
@@ -39,7 +43,8 @@ export const EXCHANGES: ExchangeInfo[] = [
     fields: [
       { key: 'api_key', label: 'API Key', type: 'password', placeholder: '请输入 API Key' },
       { key: 'api_secret', label: 'Secret Key', type: 'password', placeholder: '请输入 Secret Key' }
-    ]
+    ],
+    testnet: true
   },
   {
     id: 'backpack',
@@ -48,7 +53,8 @@ export const EXCHANGES: ExchangeInfo[] = [
     fields: [
       { key: 'api_key', label: 'API Key', type: 'password', placeholder: '请输入 API Key (公钥)' },
       { key: 'api_secret', label: 'Secret Key', type: 'password', placeholder: '请输入 Secret Key (Ed25519 私钥)' }
-    ]
+    ],
+    settleInUSDC: true
   },
   {
     id: 'lighter',
@@ -57,10 +63,31 @@ export const EXCHANGES: ExchangeInfo[] = [
     fields: [
       { key: 'api_key', label: 'API Key', type: 'password', placeholder: '请输入 API 公钥' },
       { key: 'api_secret', label: 'API Secret', type: 'password', placeholder: '请输入 API 私钥' }
-    ]
+    ],
+    settleInUSDC: true
   }
 ]
 
 export function getExchangeById(id: string): ExchangeInfo | undefined {
   return EXCHANGES.find(e => e.id === id)
 }
+
+// AccountStatus is one account as listed by /api/accounts, without credentials
+export interface AccountStatus {
+  id: string
+  name?: string
+  exchange: string
+  enabled: boolean
+  api_key_hint: string
+  testnet?: boolean
+  fill_mode?: string
+  settle_in_usdc?: boolean
+}
+
+// accountLabel names an account after its venue, adding its own name or ID
+// unless it is the venue's default account
+export function accountLabel(account: AccountStatus): string {
+  const exchangeName = getExchangeById(account.exchange)?.name || account.exchange
+  if (!account.name && account.id === account.exchange) return exchangeName
+  return `${exchangeName} · ${account.name || account.id}`
+}
@@ -5,9 +5,12 @@ import (
 	"crypto-sync-bot/internal/config"
 	"crypto-sync-bot/internal/models"
 	"crypto-sync-bot/internal/processor"
+	"errors"
 	"io"
 	"net/http"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -49,12 +52,19 @@ func (a *API) SetupRoutes(r *gin.Engine) {
 		{
 			protected.GET("/config", a.GetConfig)
 			protected.PUT("/config", a.UpdateConfig)
-			protected.PUT("/exchanges/:id", a.UpdateExchangeConfig)
-			protected.DELETE("/exchanges/:id", a.DeleteExchangeConfig)
-			protected.POST("/exchanges/:id/test", a.TestExchangeConnection)
+			protected.GET("/accounts", a.GetAccounts)
+			protected.PUT("/accounts/:id", a.UpdateAccount)
+			protected.DELETE("/accounts/:id", a.DeleteAccount)
+			protected.POST("/accounts/:id/test", a.TestAccountConnection)
 			protected.GET("/sync-items", a.GetSyncItems)
 			protected.POST("/sync-items", a.AddSyncItem)
 			protected.DELETE("/sync-items/:id", a.DeleteSyncItem)
+			protected.GET("/executions/:source/:signal_id", a.GetExecutions)
+			protected.GET("/dlq", a.ListDLQ)
+			protected.POST("/dlq/replay", a.ReplayAllDLQ)
+			protected.POST("/dlq/:id/replay", a.ReplayDLQ)
+			protected.DELETE("/dlq", a.PurgeDLQ)
+			protected.DELETE("/dlq/:id", a.DeleteDLQ)
 		}
 	}
 }
@@ -166,19 +176,20 @@ func (a *API) Restart(c *gin.Context) {
 	}()
 }
 
-func (a *API) GetConfig(c *gin.Context) {
-	type ExchangeStatus struct {
-		Enabled    bool   `json:"enabled"`
-		APIKeyHint string `json:"api_key_hint,omitempty"` // e.g., "abc1...xyz9"
-		Testnet    bool   `json:"testnet,omitempty"`
-	}
-
-	binanceCfg := a.cfg.GetBinance()
-	okxCfg := a.cfg.GetOKX()
-	bybitCfg := a.cfg.GetBybit()
-	backpackCfg := a.cfg.GetBackpack()
-	lighterCfg := a.cfg.GetLighter()
+// AccountStatus is the public view of an account, without credentials
+type AccountStatus struct {
+	ID         string `json:"id"`
+	Name       string `json:"name,omitempty"`
+	Exchange   string `json:"exchange"`
+	Enabled    bool   `json:"enabled"`
+	APIKeyHint string `json:"api_key_hint,omitempty"` // e.g., "abc1...xyz9"
+	Testnet    bool   `json:"testnet,omitempty"`
+	FillMode   string `json:"fill_mode,omitempty"`
+
+	SettleInUSDC bool `json:"settle_in_usdc,omitempty"`
+}
 
+func accountStatuses(accounts []config.AccountConfig) []AccountStatus {
 	maskKey := func(key string) string {
 		if len(key) < 8 {
 			return ""
@@ -186,36 +197,30 @@ func (a *API) GetConfig(c *gin.Context) {
 		return key[:4] + "..." + key[len(key)-4:]
 	}
 
+	statuses := make([]AccountStatus, 0, len(accounts))
+	for _, account := range accounts {
+		statuses = append(statuses, AccountStatus{
+			ID:         account.ID,
+			Name:       account.Name,
+			Exchange:   account.Exchange,
+			Enabled:    account.Configured(),
+			APIKeyHint: maskKey(account.APIKey),
+			Testnet:    account.Testnet,
+			FillMode:   account.FillMode,
+
+			SettleInUSDC: account.SettleInUSDC,
+		})
+	}
+	return statuses
+}
+
+func (a *API) GetConfig(c *gin.Context) {
 	safe := struct {
-		Binance   ExchangeStatus     `json:"binance"`
-		OKX       ExchangeStatus     `json:"okx"`
-		Bybit     ExchangeStatus     `json:"bybit"`
-		Backpack  ExchangeStatus     `json:"backpack"`
-		Lighter   ExchangeStatus     `json:"lighter"`
-		Sync      interface{}        `json:"sync"`
-		SyncItems []config.SyncItem  `json:"sync_items"`
+		Accounts  []AccountStatus   `json:"accounts"`
+		Sync      interface{}       `json:"sync"`
+		SyncItems []config.SyncItem `json:"sync_items"`
 	}{
-		Binance: ExchangeStatus{
-			Enabled:    binanceCfg.APIKey != "",
-			APIKeyHint: maskKey(binanceCfg.APIKey),
-			Testnet:    binanceCfg.Testnet,
-		},
-		OKX: ExchangeStatus{
-			Enabled:    okxCfg.APIKey != "",
-			APIKeyHint: maskKey(okxCfg.APIKey),
-		},
-		Bybit: ExchangeStatus{
-			Enabled:    bybitCfg.APIKey != "",
-			APIKeyHint: maskKey(bybitCfg.APIKey),
-		},
-		Backpack: ExchangeStatus{
-			Enabled:    backpackCfg.APIKey != "",
-			APIKeyHint: maskKey(backpackCfg.APIKey),
-		},
-		Lighter: ExchangeStatus{
-			Enabled:    lighterCfg.APIKey != "",
-			APIKeyHint: maskKey(lighterCfg.APIKey),
-		},
+		Accounts:  accountStatuses(a.cfg.GetAccounts()),
 		Sync:      a.cfg.GetSync(),
 		SyncItems: a.cfg.GetSyncItems(),
 	}
@@ -223,14 +228,26 @@ func (a *API) GetConfig(c *gin.Context) {
 	c.JSON(http.StatusOK, safe)
 }
 
-func (a *API) UpdateExchangeConfig(c *gin.Context) {
-	exchangeID := c.Param("id")
+func (a *API) GetAccounts(c *gin.Context) {
+	c.JSON(http.StatusOK, accountStatuses(a.cfg.GetAccounts()))
+}
+
+// UpdateAccount creates the account if it does not exist yet, otherwise updates it.
+// Empty credential fields and an omitted settle_in_usdc keep their current values.
+func (a *API) UpdateAccount(c *gin.Context) {
+	accountID := strings.ToLower(strings.TrimSpace(c.Param("id")))
 
 	var req struct {
-		APIKey     string `json:"api_key"`
-		APISecret  string `json:"api_secret"`
-		Passphrase string `json:"passphrase,omitempty"`
-		Testnet    bool   `json:"testnet,omitempty"`
+		Name         string `json:"name,omitempty"`
+		Exchange     string `json:"exchange"`
+		APIKey       string `json:"api_key"`
+		APISecret    string `json:"api_secret"`
+		Passphrase   string `json:"passphrase,omitempty"`
+		Testnet      bool   `json:"testnet,omitempty"`
+		AccountIndex int    `json:"account_index,omitempty"`
+		BaseURL      string `json:"base_url,omitempty"`
+		FillMode     string `json:"fill_mode,omitempty"`
+		SettleInUSDC *bool  `json:"settle_in_usdc,omitempty"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -238,52 +255,78 @@ func (a *API) UpdateExchangeConfig(c *gin.Context) {
 		return
 	}
 
-	a.cfg.UpdateExchange(exchangeID, req.APIKey, req.APISecret, req.Passphrase, req.Testnet)
+	existing, exists := a.cfg.GetAccount(accountID)
+	if req.Exchange == "" {
+		req.Exchange = existing.Exchange
+	}
+	req.Exchange = strings.ToLower(req.Exchange)
+	if !config.ValidExchange(req.Exchange) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unsupported exchange: " + req.Exchange})
+		return
+	}
+	if exists && req.Exchange != existing.Exchange {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Account exchange cannot be changed"})
+		return
+	}
+	if req.Testnet && !config.TestnetSupported(req.Exchange) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Testnet is not supported on " + req.Exchange})
+		return
+	}
+
+	// A flag cannot be left empty, so an omitted one is filled in here
+	settleInUSDC := existing.SettleInUSDC
+	if req.SettleInUSDC != nil {
+		settleInUSDC = *req.SettleInUSDC
+	}
+
+	a.cfg.UpsertAccount(config.AccountConfig{
+		ID:           accountID,
+		Name:         req.Name,
+		Exchange:     req.Exchange,
+		APIKey:       req.APIKey,
+		APISecret:    req.APISecret,
+		Passphrase:   req.Passphrase,
+		Testnet:      req.Testnet,
+		AccountIndex: req.AccountIndex,
+		BaseURL:      req.BaseURL,
+		FillMode:     req.FillMode,
+		SettleInUSDC: settleInUSDC,
+	})
 	if err := a.cfg.Save(); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save config"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Exchange config saved", "exchange": exchangeID})
+	c.JSON(http.StatusOK, gin.H{"message": "Account saved", "account": accountID})
 }
 
-func (a *API) DeleteExchangeConfig(c *gin.Context) {
-	exchangeID := c.Param("id")
+func (a *API) DeleteAccount(c *gin.Context) {
+	accountID := c.Param("id")
 
-	a.cfg.DeleteExchange(exchangeID)
+	if !a.cfg.DeleteAccount(accountID) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Account not found"})
+		return
+	}
 	if err := a.cfg.Save(); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save config"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Exchange config deleted", "exchange": exchangeID})
+	c.JSON(http.StatusOK, gin.H{"message": "Account deleted", "account": accountID})
 }
 
-func (a *API) TestExchangeConnection(c *gin.Context) {
-	exchangeID := c.Param("id")
+func (a *API) TestAccountConnection(c *gin.Context) {
+	accountID := c.Param("id")
 
 	// For now, just check if config exists - real implementation would ping the exchange API
-	var enabled bool
-	switch exchangeID {
-	case "binance":
-		enabled = a.cfg.GetBinance().APIKey != ""
-	case "okx":
-		enabled = a.cfg.GetOKX().APIKey != ""
-	case "bybit":
-		enabled = a.cfg.GetBybit().APIKey != ""
-	case "backpack":
-		enabled = a.cfg.GetBackpack().APIKey != ""
-	case "lighter":
-		enabled = a.cfg.GetLighter().APIKey != ""
-	}
-
-	if !enabled {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Exchange not configured", "success": false})
+	account, ok := a.cfg.GetAccount(accountID)
+	if !ok || !account.Configured() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Account not configured", "success": false})
 		return
 	}
 
 	// TODO: Implement actual exchange API ping
-	c.JSON(http.StatusOK, gin.H{"message": "Connection test passed", "success": true, "exchange": exchangeID})
+	c.JSON(http.StatusOK, gin.H{"message": "Connection test passed", "success": true, "account": account.ID})
 }
 
 func (a *API) UpdateConfig(c *gin.Context) {
@@ -338,5 +381,118 @@ func (a *API) PostSignal(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"status": "Signal received and queued"})
+	c.JSON(http.StatusOK, gin.H{"status": "Signal received and queued", "signal_id": signal.SignalID})
+}
+
+// requireQueue reports whether the signal queue is available, answering 503 if not
+func requireQueue(c *gin.Context) bool {
+	if !processor.QueueAvailable() {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Signal queue not available"})
+		return false
+	}
+	return true
+}
+
+// GetExecutions shows how a signal fared on each of its target accounts
+func (a *API) GetExecutions(c *gin.Context) {
+	if !requireQueue(c) {
+		return
+	}
+	executions, err := processor.GetExecutions(c.Request.Context(), c.Param("source"), c.Param("signal_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read execution state"})
+		return
+	}
+	if len(executions) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Not found"})
+		return
+	}
+	c.JSON(http.StatusOK, executions)
+}
+
+// ListDLQ pages through dead-lettered signals, oldest first. Pass the last ID
+// of a page as ?after= to get the next one.
+func (a *API) ListDLQ(c *gin.Context) {
+	if !requireQueue(c) {
+		return
+	}
+	count, err := strconv.ParseInt(c.DefaultQuery("count", "100"), 10, 64)
+	if err != nil || count <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid count"})
+		return
+	}
+
+	entries, err := processor.ListDLQ(c.Request.Context(), c.Query("after"), count)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read DLQ"})
+		return
+	}
+	c.JSON(http.StatusOK, entries)
+}
+
+// ReplayDLQ requeues one entry, optionally limited to some target accounts
+func (a *API) ReplayDLQ(c *gin.Context) {
+	if !requireQueue(c) {
+		return
+	}
+	var req struct {
+		Targets []string `json:"targets"`
+	}
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	err := processor.ReplayDLQ(c.Request.Context(), c.Param("id"), req.Targets)
+	if errors.Is(err, processor.ErrDLQEntryNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Replayed", "id": c.Param("id")})
+}
+
+func (a *API) ReplayAllDLQ(c *gin.Context) {
+	if !requireQueue(c) {
+		return
+	}
+	replayed, skipped, err := processor.ReplayAllDLQ(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error(), "replayed": replayed, "skipped": skipped})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Replayed", "replayed": replayed, "skipped": skipped})
+}
+
+func (a *API) DeleteDLQ(c *gin.Context) {
+	if !requireQueue(c) {
+		return
+	}
+	deleted, err := processor.DeleteDLQ(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete DLQ entry"})
+		return
+	}
+	if deleted == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Deleted"})
+}
+
+func (a *API) PurgeDLQ(c *gin.Context) {
+	if !requireQueue(c) {
+		return
+	}
+	deleted, err := processor.PurgeDLQ(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to purge DLQ"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Purged", "deleted": deleted})
 }
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"log"
 	"os"
+	"strings"
 	"sync"
 
 	"github.com/spf13/viper"
@@ -16,25 +17,144 @@ type AuthConfig struct {
 	IsConfigured bool   `json:"is_configured" mapstructure:"is_configured"`
 }
 
+// Sizing modes for SizingConfig.Mode
+const (
+	SizingRatio    = "ratio"     // Value is a multiplier on the source quantity
+	SizingNotional = "notional"  // Value is a fixed order size in quote currency
+	SizingEquity   = "equity"    // Source quantity scaled by target/source equity, times Value (default 1)
+	SizingFixedQty = "fixed_qty" // Value is a fixed order quantity in base currency
+)
+
+type SizingConfig struct {
+	Mode  string  `json:"mode" mapstructure:"mode"`
+	Value float64 `json:"value" mapstructure:"value"`
+}
+
+// Sync modes for SyncItem.Mode
+const (
+	SyncModeSignal   = "signal"   // Mirror each source fill as an order (default)
+	SyncModePosition = "position" // Keep target positions at a scaled copy of the source position
+)
+
+// Order mirroring for SyncItem.Mirror, in signal mode
+const (
+	MirrorFills  = "fills"  // Copy source fills as they happen (default)
+	MirrorOrders = "orders" // Copy resting limit orders when placed; their fills are not copied again
+)
+
 type SyncItem struct {
 	ID      string   `json:"id" mapstructure:"id"`
 	Name    string   `json:"name" mapstructure:"name"`
 	Enabled bool     `json:"enabled" mapstructure:"enabled"`
-	Source  string   `json:"source" mapstructure:"source"`
-	Targets []string `json:"targets" mapstructure:"targets"`
+	Source  string   `json:"source" mapstructure:"source"`   // Account ID of the lead account
+	Targets []string `json:"targets" mapstructure:"targets"` // Account IDs orders are copied to
 	Symbol  string   `json:"symbol" mapstructure:"symbol"`
+	Mode    string   `json:"mode,omitempty" mapstructure:"mode"`     // Empty means SyncModeSignal
+	Mirror  string   `json:"mirror,omitempty" mapstructure:"mirror"` // Empty means MirrorFills
+
+	// Sizing applies to every target unless overridden in TargetSizing.
+	// An empty mode falls back to SyncConfig.PositionRatio.
+	Sizing       SizingConfig            `json:"sizing" mapstructure:"sizing"`
+	TargetSizing map[string]SizingConfig `json:"target_sizing,omitempty" mapstructure:"target_sizing"`
+}
+
+// SyncItemAllSymbols is the Symbol wildcard; an empty Symbol means the same
+const SyncItemAllSymbols = "*"
+
+// AllSymbols reports whether the item mirrors every symbol traded by its source
+func (s SyncItem) AllSymbols() bool {
+	symbol := strings.TrimSpace(s.Symbol)
+	return symbol == "" || symbol == SyncItemAllSymbols
+}
+
+// MirrorsOrders reports whether resting limit orders are copied as they are placed
+func (s SyncItem) MirrorsOrders() bool {
+	return s.Mirror == MirrorOrders
+}
+
+// SizingFor returns the sizing configuration for the given target
+func (s SyncItem) SizingFor(target string) SizingConfig {
+	if sizing, ok := s.TargetSizing[target]; ok && sizing.Mode != "" {
+		return sizing
+	}
+	return s.Sizing
+}
+
+// Exchange types for AccountConfig.Exchange
+const (
+	ExchangeBinance  = "binance"
+	ExchangeOKX      = "okx"
+	ExchangeBybit    = "bybit"
+	ExchangeBackpack = "backpack"
+	ExchangeLighter  = "lighter"
+)
+
+// ValidExchange reports whether name is a supported exchange type
+func ValidExchange(name string) bool {
+	switch name {
+	case ExchangeBinance, ExchangeOKX, ExchangeBybit, ExchangeBackpack, ExchangeLighter:
+		return true
+	}
+	return false
+}
+
+// TestnetSupported reports whether accounts on exchange can set Testnet.
+// Backpack has no test network and Lighter's needs its own signing keys.
+func TestnetSupported(exchange string) bool {
+	switch exchange {
+	case ExchangeBinance, ExchangeOKX, ExchangeBybit:
+		return true
+	}
+	return false
+}
+
+// AccountConfig is one named account on an exchange. A venue can have several
+// accounts (e.g. sub-accounts); SyncItem sources and targets refer to them by ID.
+type AccountConfig struct {
+	ID         string `json:"id" mapstructure:"id"`
+	Name       string `json:"name,omitempty" mapstructure:"name"`
+	Exchange   string `json:"exchange" mapstructure:"exchange"` // One of the Exchange* constants
+	APIKey     string `json:"api_key" mapstructure:"api_key"`
+	APISecret  string `json:"api_secret" mapstructure:"api_secret"`
+	Passphrase string `json:"passphrase,omitempty" mapstructure:"passphrase"` // OKX only
+	Testnet    bool   `json:"testnet" mapstructure:"testnet"`
+
+	AccountIndex int    `json:"account_index,omitempty" mapstructure:"account_index"` // Lighter only
+	BaseURL      string `json:"base_url,omitempty" mapstructure:"base_url"`           // Override for mock servers
+	// FillMode controls how a Binance listener mirrors fills. Empty means FillModeFilled.
+	FillMode string `json:"fill_mode,omitempty" mapstructure:"fill_mode"`
+	// SettleInUSDC trades symbols quoted in another stablecoin (e.g. BTC-USDT) on
+	// the USDC perpetual. Backpack and Lighter only, which list USDC perpetuals alone.
+	SettleInUSDC bool `json:"settle_in_usdc,omitempty" mapstructure:"settle_in_usdc"`
+}
+
+// Configured reports whether the account has credentials
+func (a AccountConfig) Configured() bool {
+	return a.APIKey != "" || a.APISecret != ""
 }
 
+// Fill modes for AccountConfig.FillMode
+const (
+	FillModeFilled      = "filled"      // One signal per order once it is fully filled
+	FillModeIncremental = "incremental" // One signal per execution for the newly filled quantity
+)
+
+// The per-exchange sections below are the single-account format used before
+// Accounts existed. They are still read from the environment and old saved
+// configs, and migrated into Accounts on load.
+
 type BinanceConfig struct {
 	APIKey    string `json:"api_key" mapstructure:"api_key"`
 	APISecret string `json:"api_secret" mapstructure:"api_secret"`
 	Testnet   bool   `json:"testnet" mapstructure:"testnet"`
+	FillMode  string `json:"fill_mode,omitempty" mapstructure:"fill_mode"`
 }
 
 type OKXConfig struct {
 	APIKey     string `json:"api_key" mapstructure:"api_key"`
 	APISecret  string `json:"api_secret" mapstructure:"api_secret"`
 	Passphrase string `json:"passphrase" mapstructure:"passphrase"`
+	BaseURL    string `json:"base_url,omitempty" mapstructure:"base_url"` // Override for mock servers, defaults to https://www.okx.com
 }
 
 type BybitConfig struct {
@@ -51,6 +171,7 @@ type LighterConfig struct {
 	APIKey       string `json:"api_key" mapstructure:"api_key"`
 	APISecret    string `json:"api_secret" mapstructure:"api_secret"`
 	AccountIndex int    `json:"account_index" mapstructure:"account_index"`
+	BaseURL      string `json:"base_url,omitempty" mapstructure:"base_url"` // Override for local stubs, defaults to mainnet
 }
 
 type SyncConfig struct {
@@ -58,8 +179,23 @@ type SyncConfig struct {
 	PositionRatio float64 `json:"position_ratio" mapstructure:"position_ratio"`
 	MaxPosition   float64 `json:"max_position" mapstructure:"max_position"`
 	StopLossRatio float64 `json:"stop_loss_ratio" mapstructure:"stop_loss_ratio"`
-	OrderTimeout  int     `json:"order_timeout" mapstructure:"order_timeout"`
-	MaxRetries    int     `json:"max_retries" mapstructure:"max_retries"`
+	// OrderTimeout bounds, in seconds, the exchange calls that size and place one order
+	OrderTimeout int `json:"order_timeout" mapstructure:"order_timeout"`
+	MaxRetries   int `json:"max_retries" mapstructure:"max_retries"`
+	// PendingIdleTimeout is how long, in seconds, a delivered signal may stay
+	// unacknowledged before it is claimed and retried
+	PendingIdleTimeout int `json:"pending_idle_timeout" mapstructure:"pending_idle_timeout"`
+	// PositionSyncInterval is how often position-mode sync items are reconciled, in seconds
+	PositionSyncInterval int `json:"position_sync_interval" mapstructure:"position_sync_interval"`
+	// ConsumerBatchSize is how many signals a processor reads from each stream at once
+	ConsumerBatchSize int `json:"consumer_batch_size" mapstructure:"consumer_batch_size"`
+	// ConsumerWorkers is how many signals a processor executes concurrently.
+	// Signals for the same symbol always run one after another.
+	ConsumerWorkers int `json:"consumer_workers" mapstructure:"consumer_workers"`
+	// StreamPartitions is how many streams signals are spread over by symbol.
+	// Replicas share the partitions between them, so it bounds how many can
+	// consume at once. Read once at startup; all replicas must agree on it.
+	StreamPartitions int `json:"stream_partitions" mapstructure:"stream_partitions"`
 }
 
 type Config struct {
@@ -67,12 +203,15 @@ type Config struct {
 	WebhookSecret string     `json:"webhook_secret" mapstructure:"webhook_secret"`
 	SyncItems     []SyncItem `json:"sync_items" mapstructure:"sync_items"`
 
-	Binance BinanceConfig `json:"binance" mapstructure:"binance"`
-	OKX     OKXConfig     `json:"okx" mapstructure:"okx"`
-	Bybit   BybitConfig   `json:"bybit" mapstructure:"bybit"`
-	Backpack BackpackConfig `json:"backpack" mapstructure:"backpack"`
-	Lighter LighterConfig `json:"lighter" mapstructure:"lighter"`
-	Sync    SyncConfig    `json:"sync" mapstructure:"sync"`
+	Accounts []AccountConfig `json:"accounts" mapstructure:"accounts"`
+	Sync     SyncConfig      `json:"sync" mapstructure:"sync"`
+
+	// Legacy single-account sections, moved into Accounts by migrateLegacyAccounts
+	Binance  *BinanceConfig  `json:"binance,omitempty" mapstructure:"binance"`
+	OKX      *OKXConfig      `json:"okx,omitempty" mapstructure:"okx"`
+	Bybit    *BybitConfig    `json:"bybit,omitempty" mapstructure:"bybit"`
+	Backpack *BackpackConfig `json:"backpack,omitempty" mapstructure:"backpack"`
+	Lighter  *LighterConfig  `json:"lighter,omitempty" mapstructure:"lighter"`
 
 	mu sync.RWMutex `json:"-"`
 }
@@ -85,6 +224,11 @@ func LoadConfig() (*Config, error) {
 			var cfg Config
 			if err := json.Unmarshal(data, &cfg); err == nil {
 				log.Println("Loaded config from MySQL")
+				if cfg.migrateLegacyAccounts() {
+					if err := database.SaveConfig(&cfg); err != nil {
+						log.Printf("Warning: Failed to save migrated accounts: %v", err)
+					}
+				}
 				return &cfg, nil
 			}
 		}
@@ -97,28 +241,39 @@ func LoadConfig() (*Config, error) {
 	viper.BindEnv("binance.api_key", "BINANCE_API_KEY")
 	viper.BindEnv("binance.api_secret", "BINANCE_API_SECRET")
 	viper.BindEnv("binance.testnet", "BINANCE_TESTNET")
+	viper.BindEnv("binance.fill_mode", "BINANCE_FILL_MODE")
 	viper.BindEnv("okx.api_key", "OKX_API_KEY")
 	viper.BindEnv("okx.api_secret", "OKX_API_SECRET")
 	viper.BindEnv("okx.passphrase", "OKX_API_PASSPHRASE")
+	viper.BindEnv("okx.base_url", "OKX_BASE_URL")
 	viper.BindEnv("bybit.api_key", "BYBIT_API_KEY")
 	viper.BindEnv("bybit.api_secret", "BYBIT_API_SECRET")
+	viper.BindEnv("lighter.base_url", "LIGHTER_BASE_URL")
 	viper.BindEnv("sync.symbol", "SYMBOL")
 	viper.BindEnv("sync.position_ratio", "POSITION_RATIO")
 	viper.BindEnv("sync.max_position", "MAX_POSITION")
 	viper.BindEnv("sync.stop_loss_ratio", "STOP_LOSS_RATIO")
+	viper.BindEnv("sync.stream_partitions", "STREAM_PARTITIONS")
 
 	viper.SetDefault("binance.testnet", false)
+	viper.SetDefault("binance.fill_mode", FillModeFilled)
 	viper.SetDefault("sync.position_ratio", 1.0)
 	viper.SetDefault("sync.max_position", 1.0)
 	viper.SetDefault("sync.stop_loss_ratio", 0.05)
 	viper.SetDefault("sync.order_timeout", 30)
 	viper.SetDefault("sync.max_retries", 3)
+	viper.SetDefault("sync.pending_idle_timeout", 60)
+	viper.SetDefault("sync.position_sync_interval", 60)
+	viper.SetDefault("sync.consumer_batch_size", 10)
+	viper.SetDefault("sync.consumer_workers", 4)
+	viper.SetDefault("sync.stream_partitions", 1)
 
 	var cfg Config
 	// Viper unmarshal from Env
 	if err := viper.Unmarshal(&cfg); err != nil {
 		return nil, err
 	}
+	cfg.migrateLegacyAccounts()
 
 	// 3. Save initialized config to DB for next time
 	if database.MySQLDB != nil {
@@ -136,61 +291,83 @@ func LoadConfig() (*Config, error) {
 	return &cfg, nil
 }
 
-func (c *Config) decryptFields(key string) {
-	if c.Binance.APIKey != "" {
-		if decrypted, err := auth.Decrypt(c.Binance.APIKey, key); err == nil {
-			c.Binance.APIKey = decrypted
-		}
+// migrateLegacyAccounts moves configured single-account sections into Accounts.
+// Each becomes an account whose ID is the exchange name, so sync items written
+// before accounts existed keep resolving. It reports whether anything changed.
+func (c *Config) migrateLegacyAccounts() bool {
+	var legacy []AccountConfig
+	if c.Binance != nil {
+		legacy = append(legacy, AccountConfig{
+			Exchange:  ExchangeBinance,
+			APIKey:    c.Binance.APIKey,
+			APISecret: c.Binance.APISecret,
+			Testnet:   c.Binance.Testnet,
+			FillMode:  c.Binance.FillMode,
+		})
 	}
-	if c.Binance.APISecret != "" {
-		if decrypted, err := auth.Decrypt(c.Binance.APISecret, key); err == nil {
-			c.Binance.APISecret = decrypted
-		}
+	if c.OKX != nil {
+		legacy = append(legacy, AccountConfig{
+			Exchange:   ExchangeOKX,
+			APIKey:     c.OKX.APIKey,
+			APISecret:  c.OKX.APISecret,
+			Passphrase: c.OKX.Passphrase,
+			BaseURL:    c.OKX.BaseURL,
+		})
 	}
-	if c.OKX.APIKey != "" {
-		if decrypted, err := auth.Decrypt(c.OKX.APIKey, key); err == nil {
-			c.OKX.APIKey = decrypted
-		}
+	if c.Bybit != nil {
+		legacy = append(legacy, AccountConfig{
+			Exchange:  ExchangeBybit,
+			APIKey:    c.Bybit.APIKey,
+			APISecret: c.Bybit.APISecret,
+		})
 	}
-	if c.OKX.APISecret != "" {
-		if decrypted, err := auth.Decrypt(c.OKX.APISecret, key); err == nil {
-			c.OKX.APISecret = decrypted
-		}
-	}
-	if c.OKX.Passphrase != "" {
-		if decrypted, err := auth.Decrypt(c.OKX.Passphrase, key); err == nil {
-			c.OKX.Passphrase = decrypted
-		}
+	if c.Backpack != nil {
+		legacy = append(legacy, AccountConfig{
+			Exchange:  ExchangeBackpack,
+			APIKey:    c.Backpack.APIKey,
+			APISecret: c.Backpack.APISecret,
+		})
 	}
-	if c.Bybit.APIKey != "" {
-		if decrypted, err := auth.Decrypt(c.Bybit.APIKey, key); err == nil {
-			c.Bybit.APIKey = decrypted
-		}
+	if c.Lighter != nil {
+		legacy = append(legacy, AccountConfig{
+			Exchange:     ExchangeLighter,
+			APIKey:       c.Lighter.APIKey,
+			APISecret:    c.Lighter.APISecret,
+			AccountIndex: c.Lighter.AccountIndex,
+			BaseURL:      c.Lighter.BaseURL,
+		})
 	}
-	if c.Bybit.APISecret != "" {
-		if decrypted, err := auth.Decrypt(c.Bybit.APISecret, key); err == nil {
-			c.Bybit.APISecret = decrypted
+	c.Binance, c.OKX, c.Bybit, c.Backpack, c.Lighter = nil, nil, nil, nil, nil
+
+	changed := false
+	for _, account := range legacy {
+		if !account.Configured() {
+			continue
 		}
-	}
-	if c.Backpack.APIKey != "" {
-		if decrypted, err := auth.Decrypt(c.Backpack.APIKey, key); err == nil {
-			c.Backpack.APIKey = decrypted
+		account.ID = account.Exchange
+		if _, exists := c.findAccount(account.ID); exists {
+			continue
 		}
+		c.Accounts = append(c.Accounts, account)
+		log.Printf("Migrated %s credentials to account %q", account.Exchange, account.ID)
+		changed = true
 	}
-	if c.Backpack.APISecret != "" {
-		if decrypted, err := auth.Decrypt(c.Backpack.APISecret, key); err == nil {
-			c.Backpack.APISecret = decrypted
+	return changed
+}
+
+func (c *Config) decryptFields(key string) {
+	decrypt := func(field *string) {
+		if *field == "" {
+			return
 		}
-	}
-	if c.Lighter.APIKey != "" {
-		if decrypted, err := auth.Decrypt(c.Lighter.APIKey, key); err == nil {
-			c.Lighter.APIKey = decrypted
+		if decrypted, err := auth.Decrypt(*field, key); err == nil {
+			*field = decrypted
 		}
 	}
-	if c.Lighter.APISecret != "" {
-		if decrypted, err := auth.Decrypt(c.Lighter.APISecret, key); err == nil {
-			c.Lighter.APISecret = decrypted
-		}
+	for i := range c.Accounts {
+		decrypt(&c.Accounts[i].APIKey)
+		decrypt(&c.Accounts[i].APISecret)
+		decrypt(&c.Accounts[i].Passphrase)
 	}
 }
 
@@ -231,34 +408,32 @@ func (c *Config) GetSyncItems() []SyncItem {
 	return items
 }
 
-func (c *Config) GetBinance() BinanceConfig {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
-	return c.Binance
-}
-
-func (c *Config) GetOKX() OKXConfig {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
-	return c.OKX
-}
-
-func (c *Config) GetBybit() BybitConfig {
+func (c *Config) GetAccounts() []AccountConfig {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
-	return c.Bybit
+	accounts := make([]AccountConfig, len(c.Accounts))
+	copy(accounts, c.Accounts)
+	return accounts
 }
 
-func (c *Config) GetBackpack() BackpackConfig {
+func (c *Config) GetAccount(id string) (AccountConfig, bool) {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
-	return c.Backpack
+	i, ok := c.findAccount(id)
+	if !ok {
+		return AccountConfig{}, false
+	}
+	return c.Accounts[i], true
 }
 
-func (c *Config) GetLighter() LighterConfig {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
-	return c.Lighter
+// findAccount returns the index of the account with the given ID; callers hold c.mu
+func (c *Config) findAccount(id string) (int, bool) {
+	for i, account := range c.Accounts {
+		if strings.EqualFold(account.ID, id) {
+			return i, true
+		}
+	}
+	return -1, false
 }
 
 func (c *Config) GetSync() SyncConfig {
@@ -267,82 +442,56 @@ func (c *Config) GetSync() SyncConfig {
 	return c.Sync
 }
 
-// UpdateAll updates all exchange and sync configurations (backward compatibility)
-func (c *Config) UpdateAll(binance BinanceConfig, okx OKXConfig, bybit BybitConfig, sync SyncConfig) {
+// UpsertAccount creates or updates an account. IDs are stored lower-case.
+// On update, empty fields keep their current values to prevent overwriting
+// existing keys; Testnet and SettleInUSDC are always taken from account, so
+// callers fill them in from the current account when they are not being changed.
+func (c *Config) UpsertAccount(account AccountConfig) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
-	c.Binance = binance
-	c.OKX = okx
-	c.Bybit = bybit
-	c.Sync = sync
-}
 
-// UpdateExchange updates a single exchange configuration
-// Only non-empty fields are updated to prevent overwriting existing keys
-func (c *Config) UpdateExchange(exchangeID string, apiKey, apiSecret, passphrase string, testnet bool) {
-	c.mu.Lock()
-	defer c.mu.Unlock()
+	account.ID = strings.ToLower(strings.TrimSpace(account.ID))
+	i, ok := c.findAccount(account.ID)
+	if !ok {
+		c.Accounts = append(c.Accounts, account)
+		return
+	}
 
-	switch exchangeID {
-	case "binance":
-		if apiKey != "" {
-			c.Binance.APIKey = apiKey
-		}
-		if apiSecret != "" {
-			c.Binance.APISecret = apiSecret
-		}
-		c.Binance.Testnet = testnet
-	case "okx":
-		if apiKey != "" {
-			c.OKX.APIKey = apiKey
-		}
-		if apiSecret != "" {
-			c.OKX.APISecret = apiSecret
-		}
-		if passphrase != "" {
-			c.OKX.Passphrase = passphrase
-		}
-	case "bybit":
-		if apiKey != "" {
-			c.Bybit.APIKey = apiKey
-		}
-		if apiSecret != "" {
-			c.Bybit.APISecret = apiSecret
-		}
-	case "backpack":
-		if apiKey != "" {
-			c.Backpack.APIKey = apiKey
-		}
-		if apiSecret != "" {
-			c.Backpack.APISecret = apiSecret
-		}
-	case "lighter":
-		if apiKey != "" {
-			c.Lighter.APIKey = apiKey
-		}
-		if apiSecret != "" {
-			c.Lighter.APISecret = apiSecret
-		}
+	existing := &c.Accounts[i]
+	if account.APIKey == "" {
+		account.APIKey = existing.APIKey
+	}
+	if account.APISecret == "" {
+		account.APISecret = existing.APISecret
+	}
+	if account.Passphrase == "" {
+		account.Passphrase = existing.Passphrase
 	}
+	if account.Name == "" {
+		account.Name = existing.Name
+	}
+	if account.AccountIndex == 0 {
+		account.AccountIndex = existing.AccountIndex
+	}
+	if account.BaseURL == "" {
+		account.BaseURL = existing.BaseURL
+	}
+	if account.FillMode == "" {
+		account.FillMode = existing.FillMode
+	}
+	*existing = account
 }
 
-// DeleteExchange clears an exchange configuration
-func (c *Config) DeleteExchange(exchangeID string) {
+// DeleteAccount removes an account and reports whether it existed
+func (c *Config) DeleteAccount(id string) bool {
 	c.mu.Lock()
 	defer c.mu.Unlock()
-
-	switch exchangeID {
-	case "binance":
-		c.Binance = BinanceConfig{}
-	case "okx":
-		c.OKX = OKXConfig{}
-	case "bybit":
-		c.Bybit = BybitConfig{}
-	case "backpack":
-		c.Backpack = BackpackConfig{}
-	case "lighter":
-		c.Lighter = LighterConfig{}
+	i, ok := c.findAccount(id)
+	if !ok {
+		return false
 	}
+	c.Accounts = append(c.Accounts[:i], c.Accounts[i+1:]...)
+	return true
 }
 
 // UpdateSync updates sync configuration
@@ -0,0 +1,62 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ListenerCheckpoint records the last fill a listener turned into a signal,
+// so fills missed while disconnected can be backfilled on reconnect.
+type ListenerCheckpoint struct {
+	Source        string `gorm:"primaryKey;size:64"`
+	LastEventTime int64  // Trade time in milliseconds
+	LastTradeID   int64
+	UpdatedAt     time.Time
+}
+
+// LoadCheckpoint returns the checkpoint for a source, or nil if none was saved yet
+func LoadCheckpoint(source string) (*ListenerCheckpoint, error) {
+	if MySQLDB != nil {
+		var cp ListenerCheckpoint
+		err := MySQLDB.First(&cp, "source = ?", source).Error
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		return &cp, nil
+	}
+	if DB == nil {
+		return nil, fmt.Errorf("no database available for checkpoints")
+	}
+
+	cp := ListenerCheckpoint{Source: source}
+	err := DB.QueryRow(`SELECT last_event_time, last_trade_id FROM listener_checkpoints WHERE source = ?`, source).
+		Scan(&cp.LastEventTime, &cp.LastTradeID)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &cp, nil
+}
+
+// SaveCheckpoint inserts or replaces the checkpoint for cp.Source
+func SaveCheckpoint(cp *ListenerCheckpoint) error {
+	if MySQLDB != nil {
+		return MySQLDB.Save(cp).Error
+	}
+	if DB == nil {
+		return fmt.Errorf("no database available for checkpoints")
+	}
+
+	query := `INSERT INTO listener_checkpoints (source, last_event_time, last_trade_id, updated_at) VALUES (?, ?, ?, ?)
+	ON CONFLICT(source) DO UPDATE SET last_event_time = excluded.last_event_time, last_trade_id = excluded.last_trade_id, updated_at = excluded.updated_at`
+	_, err := DB.Exec(query, cp.Source, cp.LastEventTime, cp.LastTradeID, time.Now().Unix())
+	return err
+}
@@ -1,6 +1,7 @@
 package database
 
 import (
+	"crypto-sync-bot/internal/models"
 	"encoding/json"
 	"fmt"
 	"log"
@@ -35,6 +36,8 @@ type Order struct {
 	Status       string
 	ErrorMessage string
 	Timestamp    int64
+	FilledQuantity float64
+	AvgPrice       float64
 	CreatedAt    time.Time
 	UpdatedAt    time.Time
 }
@@ -56,7 +59,7 @@ func InitMySQL() error {
 	}
 
 	// Auto Migrate
-	err = MySQLDB.AutoMigrate(&AppConfig{}, &Order{})
+	err = MySQLDB.AutoMigrate(&AppConfig{}, &Order{}, &ListenerCheckpoint{}, &models.Outbox{}, &OrderLink{})
 	if err != nil {
 		return fmt.Errorf("failed to migrate database: %w", err)
 	}
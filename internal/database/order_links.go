@@ -0,0 +1,124 @@
+package database
+
+import (
+	"fmt"
+	"time"
+)
+
+// Order link statuses
+const (
+	OrderLinkOpen   = "open"
+	OrderLinkClosed = "closed" // the target order was cancelled or is no longer resting
+)
+
+// OrderLink ties an order placed on a target account to the source order it
+// mirrors, so cancels and amendments of the source order reach the copy.
+// A source order may have several copies per target, e.g. one per partial fill.
+type OrderLink struct {
+	ID            int64  `gorm:"primaryKey;autoIncrement"`
+	Source        string `gorm:"size:64;index:idx_order_link_source"`
+	SourceOrderID string `gorm:"size:128;index:idx_order_link_source"`
+	Target        string `gorm:"size:64;index:idx_order_link_source"`
+	Symbol        string `gorm:"size:32"`
+	OrderID       string `gorm:"size:128"`
+	Status        string `gorm:"size:16"`
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+// SaveOrderLink stores a new link and sets its ID
+func SaveOrderLink(link *OrderLink) error {
+	now := time.Now()
+	link.CreatedAt, link.UpdatedAt = now, now
+	if MySQLDB != nil {
+		return MySQLDB.Create(link).Error
+	}
+	if DB == nil {
+		return fmt.Errorf("no database available for order links")
+	}
+
+	res, err := DB.Exec(`INSERT INTO order_links (source, source_order_id, target, symbol, order_id, status, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		link.Source, link.SourceOrderID, link.Target, link.Symbol, link.OrderID, link.Status, now.UnixMilli(), now.UnixMilli())
+	if err != nil {
+		return err
+	}
+	link.ID, err = res.LastInsertId()
+	return err
+}
+
+// OpenOrderLinks returns the open copies of a source order on target, oldest first
+func OpenOrderLinks(source, sourceOrderID, target string) ([]OrderLink, error) {
+	return findOrderLinks("source = ? AND source_order_id = ? AND target = ? AND status = ?", source, sourceOrderID, target, OrderLinkOpen)
+}
+
+// OrderLinks returns every copy of a source order on target, open or not, oldest first
+func OrderLinks(source, sourceOrderID, target string) ([]OrderLink, error) {
+	return findOrderLinks("source = ? AND source_order_id = ? AND target = ?", source, sourceOrderID, target)
+}
+
+func findOrderLinks(where string, args ...interface{}) ([]OrderLink, error) {
+	if MySQLDB != nil {
+		var links []OrderLink
+		err := MySQLDB.Where(where, args...).Order("id").Find(&links).Error
+		return links, err
+	}
+	if DB == nil {
+		return nil, fmt.Errorf("no database available for order links")
+	}
+
+	rows, err := DB.Query(`SELECT id, source, source_order_id, target, symbol, order_id, status, created_at, updated_at FROM order_links
+	WHERE `+where+` ORDER BY id`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var links []OrderLink
+	for rows.Next() {
+		var l OrderLink
+		var created, updated int64
+		if err := rows.Scan(&l.ID, &l.Source, &l.SourceOrderID, &l.Target, &l.Symbol, &l.OrderID, &l.Status, &created, &updated); err != nil {
+			return nil, err
+		}
+		l.CreatedAt, l.UpdatedAt = time.UnixMilli(created), time.UnixMilli(updated)
+		links = append(links, l)
+	}
+	return links, rows.Err()
+}
+
+// HasOrderLink reports whether target has a copy of the source order, open or not
+func HasOrderLink(source, sourceOrderID, target string) (bool, error) {
+	if MySQLDB != nil {
+		var count int64
+		err := MySQLDB.Model(&OrderLink{}).Where("source = ? AND source_order_id = ? AND target = ?", source, sourceOrderID, target).
+			Count(&count).Error
+		return count > 0, err
+	}
+	if DB == nil {
+		return false, fmt.Errorf("no database available for order links")
+	}
+
+	var count int64
+	err := DB.QueryRow(`SELECT COUNT(*) FROM order_links WHERE source = ? AND source_order_id = ? AND target = ?`,
+		source, sourceOrderID, target).Scan(&count)
+	return count > 0, err
+}
+
+// UpdateOrderLink saves the target order ID and status of a link
+func UpdateOrderLink(link *OrderLink) error {
+	link.UpdatedAt = time.Now()
+	if MySQLDB != nil {
+		return MySQLDB.Model(&OrderLink{}).Where("id = ?", link.ID).Updates(map[string]interface{}{
+			"order_id":   link.OrderID,
+			"status":     link.Status,
+			"updated_at": link.UpdatedAt,
+		}).Error
+	}
+	if DB == nil {
+		return fmt.Errorf("no database available for order links")
+	}
+
+	_, err := DB.Exec(`UPDATE order_links SET order_id = ?, status = ?, updated_at = ? WHERE id = ?`,
+		link.OrderID, link.Status, link.UpdatedAt.UnixMilli(), link.ID)
+	return err
+}
@@ -0,0 +1,125 @@
+package database
+
+import (
+	"crypto-sync-bot/internal/models"
+	"fmt"
+	"time"
+)
+
+// Outbox statuses
+const (
+	OutboxPending   = "pending"
+	OutboxProcessed = "processed"
+	OutboxFailed    = "failed"
+)
+
+// InsertOutbox stores a new outbox row and sets its ID
+func InsertOutbox(o *models.Outbox) error {
+	if MySQLDB != nil {
+		return MySQLDB.Create(o).Error
+	}
+	if DB == nil {
+		return fmt.Errorf("no database available for the outbox")
+	}
+
+	res, err := DB.Exec(`INSERT INTO outbox (payload, status, attempts, last_error, next_attempt_at, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		o.Payload, o.Status, o.Attempts, o.LastError, o.NextAttemptAt.UnixMilli(), o.CreatedAt.UnixMilli(), o.UpdatedAt.UnixMilli())
+	if err != nil {
+		return err
+	}
+	o.ID, err = res.LastInsertId()
+	return err
+}
+
+// DueOutbox returns up to limit pending rows whose next attempt is due, oldest first
+func DueOutbox(now time.Time, limit int) ([]models.Outbox, error) {
+	if MySQLDB != nil {
+		var rows []models.Outbox
+		err := MySQLDB.Where("status = ? AND next_attempt_at <= ?", OutboxPending, now).
+			Order("id").Limit(limit).Find(&rows).Error
+		return rows, err
+	}
+	if DB == nil {
+		return nil, fmt.Errorf("no database available for the outbox")
+	}
+
+	rows, err := DB.Query(`SELECT id, payload, status, attempts, last_error, next_attempt_at, created_at, updated_at FROM outbox
+	WHERE status = ? AND next_attempt_at <= ? ORDER BY id LIMIT ?`, OutboxPending, now.UnixMilli(), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var due []models.Outbox
+	for rows.Next() {
+		var o models.Outbox
+		var next, created, updated int64
+		if err := rows.Scan(&o.ID, &o.Payload, &o.Status, &o.Attempts, &o.LastError, &next, &created, &updated); err != nil {
+			return nil, err
+		}
+		o.NextAttemptAt, o.CreatedAt, o.UpdatedAt = time.UnixMilli(next), time.UnixMilli(created), time.UnixMilli(updated)
+		due = append(due, o)
+	}
+	return due, rows.Err()
+}
+
+// ClaimOutbox pushes a due row's next attempt to until, so that concurrent
+// relays do not publish it at the same time. It reports whether the row was
+// still due, i.e. whether this caller now owns the attempt.
+func ClaimOutbox(o *models.Outbox, until time.Time) (bool, error) {
+	if MySQLDB != nil {
+		res := MySQLDB.Model(&models.Outbox{}).
+			Where("id = ? AND status = ? AND next_attempt_at = ?", o.ID, OutboxPending, o.NextAttemptAt).
+			Update("next_attempt_at", until)
+		return res.RowsAffected == 1, res.Error
+	}
+	if DB == nil {
+		return false, fmt.Errorf("no database available for the outbox")
+	}
+
+	res, err := DB.Exec(`UPDATE outbox SET next_attempt_at = ? WHERE id = ? AND status = ? AND next_attempt_at = ?`,
+		until.UnixMilli(), o.ID, OutboxPending, o.NextAttemptAt.UnixMilli())
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
+
+// UpdateOutbox saves the status, attempts, error and schedule of a row
+func UpdateOutbox(o *models.Outbox) error {
+	o.UpdatedAt = time.Now()
+	if MySQLDB != nil {
+		return MySQLDB.Model(&models.Outbox{}).Where("id = ?", o.ID).Updates(map[string]interface{}{
+			"status":          o.Status,
+			"attempts":        o.Attempts,
+			"last_error":      o.LastError,
+			"next_attempt_at": o.NextAttemptAt,
+			"updated_at":      o.UpdatedAt,
+		}).Error
+	}
+	if DB == nil {
+		return fmt.Errorf("no database available for the outbox")
+	}
+
+	_, err := DB.Exec(`UPDATE outbox SET status = ?, attempts = ?, last_error = ?, next_attempt_at = ?, updated_at = ? WHERE id = ?`,
+		o.Status, o.Attempts, o.LastError, o.NextAttemptAt.UnixMilli(), o.UpdatedAt.UnixMilli(), o.ID)
+	return err
+}
+
+// PruneOutbox deletes processed rows last updated before cutoff
+func PruneOutbox(cutoff time.Time) (int64, error) {
+	if MySQLDB != nil {
+		res := MySQLDB.Where("status = ? AND updated_at < ?", OutboxProcessed, cutoff).Delete(&models.Outbox{})
+		return res.RowsAffected, res.Error
+	}
+	if DB == nil {
+		return 0, fmt.Errorf("no database available for the outbox")
+	}
+
+	res, err := DB.Exec(`DELETE FROM outbox WHERE status = ? AND updated_at < ?`, OutboxProcessed, cutoff.UnixMilli())
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,297 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// QueuedSignal is a signal in the embedded SQLite queue, used when Redis is
+// not configured. A row with Deliveries == 0 has not been read yet; one with
+// Deliveries > 0 is pending until it is acked (deleted) or dead-lettered.
+type QueuedSignal struct {
+	ID          int64
+	Payload     string
+	Targets     string // comma-separated target restriction, empty for all
+	Deliveries  int64
+	DeliveredAt int64 // milliseconds
+	CreatedAt   int64 // milliseconds
+}
+
+// DeadLetter is a signal that exhausted its retries in the embedded queue
+type DeadLetter struct {
+	ID         int64
+	Payload    string
+	Targets    string
+	Errors     string // JSON object of target -> last error
+	Reason     string
+	Deliveries int64
+	MessageID  string
+	QueuedAt   int64 // milliseconds
+	FailedAt   int64 // milliseconds
+}
+
+func createQueueTables() error {
+	_, err := DB.Exec(`
+	CREATE TABLE IF NOT EXISTS queue_messages (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		payload TEXT NOT NULL,
+		targets TEXT DEFAULT '',
+		deliveries INTEGER DEFAULT 0,
+		delivered_at INTEGER DEFAULT 0,
+		created_at INTEGER
+	);
+	CREATE TABLE IF NOT EXISTS queue_dlq (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		payload TEXT NOT NULL,
+		targets TEXT DEFAULT '',
+		errors TEXT DEFAULT '',
+		reason TEXT DEFAULT '',
+		deliveries INTEGER DEFAULT 0,
+		message_id TEXT DEFAULT '',
+		queued_at INTEGER,
+		failed_at INTEGER
+	);
+	CREATE TABLE IF NOT EXISTS reservations (
+		key TEXT PRIMARY KEY,
+		status TEXT NOT NULL,
+		client_order_id TEXT,
+		reserved_at INTEGER,
+		expires_at INTEGER
+	);
+	CREATE TABLE IF NOT EXISTS executions (
+		key TEXT NOT NULL,
+		target TEXT NOT NULL,
+		state TEXT NOT NULL,
+		expires_at INTEGER,
+		PRIMARY KEY (key, target)
+	);`)
+	return err
+}
+
+func requireSQLite() error {
+	if DB == nil {
+		return fmt.Errorf("sqlite not initialized")
+	}
+	return nil
+}
+
+// EnqueueSignal appends a signal to the embedded queue
+func EnqueueSignal(payload, targets string, now int64) (int64, error) {
+	if err := requireSQLite(); err != nil {
+		return 0, err
+	}
+	res, err := DB.Exec(`INSERT INTO queue_messages (payload, targets, created_at) VALUES (?, ?, ?)`, payload, targets, now)
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+// DeliverQueued marks up to limit unread signals as delivered and returns them, oldest first
+func DeliverQueued(limit int64, now int64) ([]QueuedSignal, error) {
+	if err := requireSQLite(); err != nil {
+		return nil, err
+	}
+	tx, err := DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	signals, err := scanQueued(tx.Query(`SELECT id, payload, targets, deliveries, delivered_at, created_at FROM queue_messages
+	WHERE deliveries = 0 ORDER BY id LIMIT ?`, limit))
+	if err != nil {
+		return nil, err
+	}
+	for i := range signals {
+		if _, err := tx.Exec(`UPDATE queue_messages SET deliveries = 1, delivered_at = ? WHERE id = ?`, now, signals[i].ID); err != nil {
+			return nil, err
+		}
+		signals[i].Deliveries, signals[i].DeliveredAt = 1, now
+	}
+	return signals, tx.Commit()
+}
+
+// PendingQueued returns delivered but unacknowledged signals, oldest first
+func PendingQueued(limit int64) ([]QueuedSignal, error) {
+	if err := requireSQLite(); err != nil {
+		return nil, err
+	}
+	return scanQueued(DB.Query(`SELECT id, payload, targets, deliveries, delivered_at, created_at FROM queue_messages
+	WHERE deliveries > 0 ORDER BY id LIMIT ?`, limit))
+}
+
+// RedeliverQueued counts another delivery of a pending signal, unless it was
+// redelivered or acked since it was read as q
+func RedeliverQueued(q *QueuedSignal, now int64) (bool, error) {
+	if err := requireSQLite(); err != nil {
+		return false, err
+	}
+	res, err := DB.Exec(`UPDATE queue_messages SET deliveries = deliveries + 1, delivered_at = ? WHERE id = ? AND deliveries = ?`,
+		now, q.ID, q.Deliveries)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	if n == 1 {
+		q.Deliveries++
+		q.DeliveredAt = now
+	}
+	return n == 1, err
+}
+
+// AckQueued removes a processed signal from the embedded queue
+func AckQueued(id int64) error {
+	if err := requireSQLite(); err != nil {
+		return err
+	}
+	_, err := DB.Exec(`DELETE FROM queue_messages WHERE id = ?`, id)
+	return err
+}
+
+// GetQueued returns one queued signal
+func GetQueued(id int64) (*QueuedSignal, error) {
+	if err := requireSQLite(); err != nil {
+		return nil, err
+	}
+	var q QueuedSignal
+	err := DB.QueryRow(`SELECT id, payload, targets, deliveries, delivered_at, created_at FROM queue_messages WHERE id = ?`, id).
+		Scan(&q.ID, &q.Payload, &q.Targets, &q.Deliveries, &q.DeliveredAt, &q.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &q, nil
+}
+
+func scanQueued(rows *sql.Rows, err error) ([]QueuedSignal, error) {
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var signals []QueuedSignal
+	for rows.Next() {
+		var q QueuedSignal
+		if err := rows.Scan(&q.ID, &q.Payload, &q.Targets, &q.Deliveries, &q.DeliveredAt, &q.CreatedAt); err != nil {
+			return nil, err
+		}
+		signals = append(signals, q)
+	}
+	return signals, rows.Err()
+}
+
+// MoveToDeadLetter stores d and removes the signal it came from, atomically
+func MoveToDeadLetter(queuedID int64, d *DeadLetter) error {
+	if err := requireSQLite(); err != nil {
+		return err
+	}
+	tx, err := DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`INSERT INTO queue_dlq (payload, targets, errors, reason, deliveries, message_id, queued_at, failed_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		d.Payload, d.Targets, d.Errors, d.Reason, d.Deliveries, d.MessageID, d.QueuedAt, d.FailedAt); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM queue_messages WHERE id = ?`, queuedID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// RequeueDeadLetter queues a dead letter's signal again with the given target
+// restriction and removes the dead letter, atomically
+func RequeueDeadLetter(id int64, targets string, now int64) error {
+	if err := requireSQLite(); err != nil {
+		return err
+	}
+	tx, err := DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(`INSERT INTO queue_messages (payload, targets, created_at) SELECT payload, ?, ? FROM queue_dlq WHERE id = ?`, targets, now, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	if _, err := tx.Exec(`DELETE FROM queue_dlq WHERE id = ?`, id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// GetDeadLetter returns one dead letter
+func GetDeadLetter(id int64) (*DeadLetter, error) {
+	if err := requireSQLite(); err != nil {
+		return nil, err
+	}
+	var d DeadLetter
+	err := DB.QueryRow(`SELECT id, payload, targets, errors, reason, deliveries, message_id, queued_at, failed_at FROM queue_dlq WHERE id = ?`, id).
+		Scan(&d.ID, &d.Payload, &d.Targets, &d.Errors, &d.Reason, &d.Deliveries, &d.MessageID, &d.QueuedAt, &d.FailedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+// ListDeadLetters returns up to limit dead letters with an ID above after, oldest first
+func ListDeadLetters(after, limit int64) ([]DeadLetter, error) {
+	if err := requireSQLite(); err != nil {
+		return nil, err
+	}
+	rows, err := DB.Query(`SELECT id, payload, targets, errors, reason, deliveries, message_id, queued_at, failed_at FROM queue_dlq
+	WHERE id > ? ORDER BY id LIMIT ?`, after, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var letters []DeadLetter
+	for rows.Next() {
+		var d DeadLetter
+		if err := rows.Scan(&d.ID, &d.Payload, &d.Targets, &d.Errors, &d.Reason, &d.Deliveries, &d.MessageID, &d.QueuedAt, &d.FailedAt); err != nil {
+			return nil, err
+		}
+		letters = append(letters, d)
+	}
+	return letters, rows.Err()
+}
+
+// DeleteDeadLetters removes dead letters by ID and returns how many existed
+func DeleteDeadLetters(ids ...int64) (int64, error) {
+	if err := requireSQLite(); err != nil {
+		return 0, err
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(ids)), ",")
+	res, err := DB.Exec(`DELETE FROM queue_dlq WHERE id IN (`+placeholders+`)`, args...)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// PurgeDeadLetters removes every dead letter
+func PurgeDeadLetters() (int64, error) {
+	if err := requireSQLite(); err != nil {
+		return 0, err
+	}
+	res, err := DB.Exec(`DELETE FROM queue_dlq`)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
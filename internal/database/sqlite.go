@@ -3,14 +3,25 @@ package database
 import (
 	"crypto-sync-bot/internal/models"
 	"database/sql"
+	"fmt"
+	"strings"
+
 	_ "modernc.org/sqlite"
 )
 
 var DB *sql.DB
 
 func InitSQLite(path string) error {
+	// The embedded signal queue writes from several goroutines; WAL plus a busy
+	// timeout lets them wait for the write lock instead of failing
+	sep := "?"
+	if strings.Contains(path, "?") {
+		sep = "&"
+	}
+	dsn := path + sep + "_pragma=busy_timeout(5000)&_pragma=journal_mode(wal)"
+
 	var err error
-	DB, err = sql.Open("sqlite", path)
+	DB, err = sql.Open("sqlite", dsn)
 	if err != nil {
 		return err
 	}
@@ -26,8 +37,77 @@ func InitSQLite(path string) error {
 		timestamp INTEGER
 	);`
 
-	_, err = DB.Exec(query)
-	return err
+	if _, err = DB.Exec(query); err != nil {
+		return err
+	}
+
+	// Columns added after the initial schema
+	if err = addColumn("orders", "filled_quantity", "REAL DEFAULT 0"); err != nil {
+		return err
+	}
+	if err = addColumn("orders", "avg_price", "REAL DEFAULT 0"); err != nil {
+		return err
+	}
+
+	_, err = DB.Exec(`
+	CREATE TABLE IF NOT EXISTS listener_checkpoints (
+		source TEXT PRIMARY KEY,
+		last_event_time INTEGER,
+		last_trade_id INTEGER,
+		updated_at INTEGER
+	);`)
+	if err != nil {
+		return err
+	}
+
+	// Times are stored as Unix milliseconds
+	_, err = DB.Exec(`
+	CREATE TABLE IF NOT EXISTS outbox (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		payload TEXT NOT NULL,
+		status TEXT NOT NULL,
+		attempts INTEGER DEFAULT 0,
+		last_error TEXT DEFAULT '',
+		next_attempt_at INTEGER,
+		created_at INTEGER,
+		updated_at INTEGER
+	);
+	CREATE INDEX IF NOT EXISTS idx_outbox_due ON outbox (status, next_attempt_at);`)
+	if err != nil {
+		return err
+	}
+
+	_, err = DB.Exec(`
+	CREATE TABLE IF NOT EXISTS order_links (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		source TEXT NOT NULL,
+		source_order_id TEXT NOT NULL,
+		target TEXT NOT NULL,
+		symbol TEXT,
+		order_id TEXT,
+		status TEXT NOT NULL,
+		created_at INTEGER,
+		updated_at INTEGER
+	);
+	CREATE INDEX IF NOT EXISTS idx_order_link_source ON order_links (source, source_order_id, target);`)
+	if err != nil {
+		return err
+	}
+
+	return createQueueTables()
+}
+
+// addColumn adds a column to an existing table. Databases created after the
+// column was introduced already have it, which is not an error.
+func addColumn(table, column, definition string) error {
+	_, err := DB.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition))
+	if err != nil && strings.Contains(err.Error(), "duplicate column name") {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to add column %s.%s: %w", table, column, err)
+	}
+	return nil
 }
 
 func SaveOrderResult(res *models.OrderResult) error {
@@ -39,12 +119,14 @@ func SaveOrderResult(res *models.OrderResult) error {
 			Status:        res.Status,
 			ErrorMessage:  res.ErrorMessage,
 			Timestamp:     res.Timestamp,
+			FilledQuantity: res.FilledQuantity,
+			AvgPrice:       res.AvgPrice,
 			// Map other fields if needed
 		}
 		return MySQLDB.Create(&order).Error
 	}
 
-	query := `INSERT INTO orders (exchange, symbol, order_id, status, error_message, timestamp) VALUES (?, ?, ?, ?, ?, ?)`
-	_, err := DB.Exec(query, res.Exchange, res.Symbol, res.OrderID, res.Status, res.ErrorMessage, res.Timestamp)
+	query := `INSERT INTO orders (exchange, symbol, order_id, status, error_message, timestamp, filled_quantity, avg_price) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
+	_, err := DB.Exec(query, res.Exchange, res.Symbol, res.OrderID, res.Status, res.ErrorMessage, res.Timestamp, res.FilledQuantity, res.AvgPrice)
 	return err
 }
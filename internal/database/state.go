@@ -0,0 +1,135 @@
+package database
+
+import (
+	"database/sql"
+	"sync"
+)
+
+// Reservation and execution state for the embedded (no Redis) mode. Rows
+// expire like their Redis counterparts; expired ones are ignored and replaced.
+
+// ReservationRow is the idempotency record of a signal on one target
+type ReservationRow struct {
+	Status        string
+	ClientOrderID string
+	ReservedAt    int64
+}
+
+// executionsMu serializes read-modify-write updates of execution state; the
+// embedded store is only used by a single process
+var executionsMu sync.Mutex
+
+// ReserveKey inserts an in-flight reservation for key unless a live one exists,
+// in which case the existing reservation is returned
+func ReserveKey(key, clientOrderID string, now, expiresAt int64) (*ReservationRow, bool, error) {
+	if err := requireSQLite(); err != nil {
+		return nil, false, err
+	}
+	res, err := DB.Exec(`INSERT INTO reservations (key, status, client_order_id, reserved_at, expires_at) VALUES (?, 'in_flight', ?, ?, ?)
+	ON CONFLICT(key) DO UPDATE SET status = 'in_flight', client_order_id = excluded.client_order_id,
+		reserved_at = excluded.reserved_at, expires_at = excluded.expires_at
+	WHERE reservations.expires_at <= ?`, key, clientOrderID, now, expiresAt, now)
+	if err != nil {
+		return nil, false, err
+	}
+	if n, _ := res.RowsAffected(); n == 1 {
+		return nil, true, nil
+	}
+
+	var row ReservationRow
+	err = DB.QueryRow(`SELECT status, client_order_id, reserved_at FROM reservations WHERE key = ?`, key).
+		Scan(&row.Status, &row.ClientOrderID, &row.ReservedAt)
+	if err != nil {
+		return nil, false, err
+	}
+	return &row, false, nil
+}
+
+// TakeOverReservation renews an in-flight reservation if it is still the one observed
+func TakeOverReservation(key string, observed, now int64) (bool, error) {
+	if err := requireSQLite(); err != nil {
+		return false, err
+	}
+	res, err := DB.Exec(`UPDATE reservations SET reserved_at = ? WHERE key = ? AND status = 'in_flight' AND reserved_at = ?`, now, key, observed)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
+
+// SetReservationStatus updates the status of a reservation
+func SetReservationStatus(key, status string) error {
+	if err := requireSQLite(); err != nil {
+		return err
+	}
+	_, err := DB.Exec(`UPDATE reservations SET status = ? WHERE key = ?`, status, key)
+	return err
+}
+
+// DeleteReservation drops a reservation
+func DeleteReservation(key string) error {
+	if err := requireSQLite(); err != nil {
+		return err
+	}
+	_, err := DB.Exec(`DELETE FROM reservations WHERE key = ?`, key)
+	return err
+}
+
+// UpdateExecution replaces the state of key on target with the result of
+// update, which receives the current live state or "" if there is none.
+// Returning "" from update leaves the row untouched.
+func UpdateExecution(key, target string, now, expiresAt int64, update func(current string) string) error {
+	if err := requireSQLite(); err != nil {
+		return err
+	}
+	executionsMu.Lock()
+	defer executionsMu.Unlock()
+
+	var current string
+	err := DB.QueryRow(`SELECT state FROM executions WHERE key = ? AND target = ? AND expires_at > ?`, key, target, now).Scan(&current)
+	if err != nil && err != sql.ErrNoRows {
+		return err
+	}
+	next := update(current)
+	if next == "" {
+		return nil
+	}
+	_, err = DB.Exec(`INSERT INTO executions (key, target, state, expires_at) VALUES (?, ?, ?, ?)
+	ON CONFLICT(key, target) DO UPDATE SET state = excluded.state, expires_at = excluded.expires_at`, key, target, next, expiresAt)
+	return err
+}
+
+// ListExecutions returns the live states of key by target
+func ListExecutions(key string, now int64) (map[string]string, error) {
+	if err := requireSQLite(); err != nil {
+		return nil, err
+	}
+	rows, err := DB.Query(`SELECT target, state FROM executions WHERE key = ? AND expires_at > ?`, key, now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	states := make(map[string]string)
+	for rows.Next() {
+		var target, state string
+		if err := rows.Scan(&target, &state); err != nil {
+			return nil, err
+		}
+		states[target] = state
+	}
+	return states, rows.Err()
+}
+
+// PruneState deletes expired reservations and execution states
+func PruneState(now int64) error {
+	if err := requireSQLite(); err != nil {
+		return err
+	}
+	if _, err := DB.Exec(`DELETE FROM reservations WHERE expires_at <= ?`, now); err != nil {
+		return err
+	}
+	_, err := DB.Exec(`DELETE FROM executions WHERE expires_at <= ?`, now)
+	return err
+}
@@ -0,0 +1,27 @@
+package exchange
+
+import (
+	"context"
+	"crypto-sync-bot/internal/models"
+)
+
+// replaceOrder finishes an amendment done by cancel and replace: once the
+// original order is cancelled with filled already executed, the rest of the
+// signal's total quantity is placed as a new limit order.
+func replaceOrder(ctx context.Context, executor models.ExchangeExecutor, orderID string, signal *models.TradingSignal, filled float64) (*models.OrderResult, error) {
+	rest := *signal
+	rest.OrderType = "LIMIT"
+	rest.Quantity = signal.Quantity - filled
+	if rest.Quantity <= 0 {
+		// Already filled up to the new quantity, nothing is left to rest
+		return &models.OrderResult{
+			Exchange:       executor.Name(),
+			Symbol:         signal.Symbol,
+			OrderID:        orderID,
+			Status:         "CANCELLED",
+			Timestamp:      signal.Timestamp,
+			FilledQuantity: filled,
+		}, nil
+	}
+	return executor.PlaceOrder(ctx, &rest)
+}
@@ -2,6 +2,7 @@ package exchange
 
 import (
 	"bytes"
+	"context"
 	"crypto/ed25519"
 	"encoding/base64"
 	"encoding/json"
@@ -20,22 +21,32 @@ import (
 
 const backpackBaseURL = "https://api.backpack.exchange"
 
+func init() {
+	RegisterExecutor("backpack", func(account config.AccountConfig) (models.ExchangeExecutor, error) {
+		executor, err := NewBackpackExecutor(account)
+		if err != nil || executor == nil {
+			// Avoid returning a typed nil inside the interface
+			return nil, err
+		}
+		return executor, nil
+	})
+}
+
 type BackpackExecutor struct {
-	config     *config.Config
+	account    config.AccountConfig
 	httpClient *http.Client
 	privateKey ed25519.PrivateKey
+	symbols    backpackSymbols
 }
 
-func NewBackpackExecutor(cfg *config.Config) (*BackpackExecutor, error) {
-	backpackCfg := cfg.GetBackpack()
-	
+func NewBackpackExecutor(account config.AccountConfig) (*BackpackExecutor, error) {
 	// If API secret is not configured, return nil (will be configured later via admin panel)
-	if backpackCfg.APISecret == "" {
+	if account.APISecret == "" {
 		return nil, nil
 	}
 	
 	// Decode the secret key (Base64 encoded Ed25519 private key)
-	privateKeyBytes, err := base64.StdEncoding.DecodeString(backpackCfg.APISecret)
+	privateKeyBytes, err := base64.StdEncoding.DecodeString(account.APISecret)
 	if err != nil {
 		return nil, fmt.Errorf("failed to decode backpack secret: %w", err)
 	}
@@ -51,9 +62,10 @@ func NewBackpackExecutor(cfg *config.Config) (*BackpackExecutor, error) {
 	}
 	
 	return &BackpackExecutor{
-		config:     cfg,
-		httpClient: &http.Client{Timeout: 30 * time.Second},
+		account:    account,
+		httpClient: &http.Client{},
 		privateKey: privateKey,
+		symbols:    backpackSymbols{stablecoinsAsUSDC: account.SettleInUSDC},
 	}, nil
 }
 
@@ -61,7 +73,7 @@ func (e *BackpackExecutor) Name() string {
 	return "Backpack"
 }
 
-func (e *BackpackExecutor) PlaceOrder(signal *models.TradingSignal) (*models.OrderResult, error) {
+func (e *BackpackExecutor) PlaceOrder(ctx context.Context, signal *models.TradingSignal) (*models.OrderResult, error) {
 	// Map side: Backpack uses "Bid" for buy, "Ask" for sell
 	side := "Bid"
 	if signal.Side == "SELL" {
@@ -74,24 +86,43 @@ func (e *BackpackExecutor) PlaceOrder(signal *models.TradingSignal) (*models.Ord
 		orderType = "Limit"
 	}
 	
-	// Convert symbol format: BTCUSDT -> BTC_USDT
-	symbol := convertSymbol(signal.Symbol)
+	// Convert symbol format: BTC-USDT -> BTC_USDC_PERP
+	symbol, err := nativeSymbol(e.symbols, signal.Symbol)
+	if err != nil {
+		return &models.OrderResult{
+			Exchange:     "Backpack",
+			Symbol:       signal.Symbol,
+			Status:       "failed",
+			ErrorMessage: err.Error(),
+			Timestamp:    signal.Timestamp,
+		}, err
+	}
 	
 	// Build order params
 	params := map[string]string{
 		"symbol":    symbol,
 		"side":      side,
 		"orderType": orderType,
-		"quantity":  fmt.Sprintf("%f", signal.Quantity),
+		"quantity":  formatDecimal(signal.Quantity),
 	}
 	
 	if orderType == "Limit" {
-		params["price"] = fmt.Sprintf("%f", signal.Price)
+		params["price"] = formatDecimal(signal.Price)
 		params["timeInForce"] = "GTC"
 	}
+	if signal.ClientOrderID != "" {
+		params["clientId"] = strconv.FormatUint(clientOrderNumber(signal.ClientOrderID, 32), 10)
+	}
+	// Stop-loss and take-profit are triggered at market once the order has filled
+	if signal.StopLossPrice > 0 {
+		params["stopLossTriggerPrice"] = formatDecimal(signal.StopLossPrice)
+	}
+	if signal.TakeProfitPrice > 0 {
+		params["takeProfitTriggerPrice"] = formatDecimal(signal.TakeProfitPrice)
+	}
 	
 	// Make request
-	respBody, err := e.signedRequest("POST", "/api/v1/order", "orderExecute", params)
+	respBody, err := e.signedRequest(ctx, "POST", "/api/v1/order", "orderExecute", params)
 	if err != nil {
 		log.Printf("Backpack Order Failed: %v", err)
 		return &models.OrderResult{
@@ -121,31 +152,215 @@ func (e *BackpackExecutor) PlaceOrder(signal *models.TradingSignal) (*models.Ord
 	}, nil
 }
 
-func (e *BackpackExecutor) GetOrder(orderID, symbol string) (*models.OrderResult, error) {
+// SupportsConditionalOrders reports that stop-loss and take-profit are attached on placement
+func (e *BackpackExecutor) SupportsConditionalOrders() bool {
+	return true
+}
+
+func (e *BackpackExecutor) GetOrder(ctx context.Context, orderID, symbol string) (*models.OrderResult, error) {
+	return e.findOrder(ctx, symbol, "orderId", orderID)
+}
+
+// GetOrderByClientID looks up an order by the client ID derived from clientOrderID
+func (e *BackpackExecutor) GetOrderByClientID(ctx context.Context, clientOrderID, symbol string) (*models.OrderResult, error) {
+	clientID := strconv.FormatUint(clientOrderNumber(clientOrderID, 32), 10)
+	result, err := e.findOrder(ctx, symbol, "clientId", clientID)
+	if err != nil && strings.Contains(err.Error(), "backpack API error 404") {
+		return nil, fmt.Errorf("backpack order %s: %w", clientOrderID, models.ErrOrderNotFound)
+	}
+	return result, err
+}
+
+func (e *BackpackExecutor) CancelOrder(ctx context.Context, orderID, symbol string) error {
+	_, err := e.cancelOrder(ctx, orderID, symbol)
+	return err
+}
+
+// AmendOrder cancels and replaces the order, as Backpack has no order modification
+func (e *BackpackExecutor) AmendOrder(ctx context.Context, orderID string, signal *models.TradingSignal) (*models.OrderResult, error) {
+	filled, err := e.cancelOrder(ctx, orderID, signal.Symbol)
+	if err != nil {
+		return nil, err
+	}
+	return replaceOrder(ctx, e, orderID, signal, filled)
+}
+
+// cancelOrder cancels an order and returns the quantity it had executed
+func (e *BackpackExecutor) cancelOrder(ctx context.Context, orderID, symbol string) (float64, error) {
+	native, err := nativeSymbol(e.symbols, symbol)
+	if err != nil {
+		return 0, err
+	}
+
 	params := map[string]string{
 		"orderId": orderID,
-		"symbol":  convertSymbol(symbol),
+		"symbol":  native,
+	}
+	respBody, err := e.signedRequest(ctx, "DELETE", "/api/v1/order", "orderCancel", params)
+	if err != nil {
+		return 0, err
+	}
+
+	var resp struct {
+		ExecutedQuantity string `json:"executedQuantity"`
+	}
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return 0, fmt.Errorf("failed to parse response: %w", err)
+	}
+	filled, _ := strconv.ParseFloat(resp.ExecutedQuantity, 64)
+	return filled, nil
+}
+
+// findOrder queries one order by idField ("orderId" or "clientId")
+func (e *BackpackExecutor) findOrder(ctx context.Context, symbol, idField, id string) (*models.OrderResult, error) {
+	native, err := nativeSymbol(e.symbols, symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	params := map[string]string{
+		idField:  id,
+		"symbol": native,
 	}
 	
-	respBody, err := e.signedRequest("GET", "/api/v1/order", "orderQuery", params)
+	respBody, err := e.signedRequest(ctx, "GET", "/api/v1/order", "orderQuery", params)
 	if err != nil {
 		return nil, err
 	}
 	
 	var resp struct {
-		ID     string `json:"id"`
-		Status string `json:"status"`
+		ID               string `json:"id"`
+		Status           string `json:"status"`
+		ExecutedQuantity string `json:"executedQuantity"`
 	}
 	if err := json.Unmarshal(respBody, &resp); err != nil {
 		return nil, err
 	}
 	
-	return &models.OrderResult{
+	result := &models.OrderResult{
 		Exchange: "Backpack",
 		Symbol:   symbol,
 		OrderID:  resp.ID,
-		Status:   resp.Status,
-	}, nil
+		Status:   mapBackpackOrderStatus(resp.Status),
+	}
+	result.FilledQuantity, _ = strconv.ParseFloat(resp.ExecutedQuantity, 64)
+	return result, nil
+}
+
+// mapBackpackOrderStatus maps Backpack order statuses to the normalized ones
+func mapBackpackOrderStatus(status string) string {
+	switch status {
+	case "New", "TriggerPending":
+		return "NEW"
+	case "PartiallyFilled":
+		return "PARTIALLY_FILLED"
+	case "Filled":
+		return "FILLED"
+	case "Cancelled", "Expired", "TriggerFailed":
+		return "CANCELLED"
+	default:
+		return strings.ToUpper(status)
+	}
+}
+
+// GetBalance returns the net equity of the collateral account. Backpack
+// futures margin is cross-collateralized, so asset is informational only.
+func (e *BackpackExecutor) GetBalance(ctx context.Context, asset string) (float64, error) {
+	respBody, err := e.signedRequest(ctx, "GET", "/api/v1/capital/collateral", "collateralQuery", map[string]string{})
+	if err != nil {
+		return 0, err
+	}
+
+	var resp struct {
+		NetEquity string `json:"netEquity"`
+	}
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return 0, err
+	}
+	return parseFloat(resp.NetEquity)
+}
+
+// GetPosition returns the net futures position in symbol
+func (e *BackpackExecutor) GetPosition(ctx context.Context, symbol string) (*models.Position, error) {
+	native, err := nativeSymbol(e.symbols, symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	respBody, err := e.signedRequest(ctx, "GET", "/api/v1/position", "positionQuery", map[string]string{})
+	if err != nil {
+		return nil, err
+	}
+
+	var positions []struct {
+		Symbol      string `json:"symbol"`
+		NetQuantity string `json:"netQuantity"` // Signed: negative is short
+		EntryPrice  string `json:"entryPrice"`
+	}
+	if err := json.Unmarshal(respBody, &positions); err != nil {
+		return nil, err
+	}
+
+	position := &models.Position{Symbol: symbol}
+	for _, p := range positions {
+		if p.Symbol != native {
+			continue
+		}
+		if position.Size, err = parseFloat(p.NetQuantity); err != nil {
+			return nil, err
+		}
+		position.EntryPrice, _ = strconv.ParseFloat(p.EntryPrice, 64)
+	}
+	return position, nil
+}
+
+func (e *BackpackExecutor) GetInstrument(ctx context.Context, symbol string) (*models.Instrument, error) {
+	native, err := nativeSymbol(e.symbols, symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := requestContext(ctx)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", backpackBaseURL+"/api/v1/market?symbol="+native, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := e.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 400 {
+		return nil, fmt.Errorf("backpack API error %d: %s", resp.StatusCode, string(body))
+	}
+
+	var market struct {
+		Filters struct {
+			Price struct {
+				TickSize string `json:"tickSize"`
+			} `json:"price"`
+			Quantity struct {
+				MinQuantity string `json:"minQuantity"`
+				StepSize    string `json:"stepSize"`
+			} `json:"quantity"`
+		} `json:"filters"`
+	}
+	if err := json.Unmarshal(body, &market); err != nil {
+		return nil, fmt.Errorf("failed to parse market: %w", err)
+	}
+
+	instrument := &models.Instrument{Symbol: symbol}
+	instrument.MinQty, _ = strconv.ParseFloat(market.Filters.Quantity.MinQuantity, 64)
+	instrument.QtyStep, _ = strconv.ParseFloat(market.Filters.Quantity.StepSize, 64)
+	instrument.TickSize, _ = strconv.ParseFloat(market.Filters.Price.TickSize, 64)
+	return instrument, nil
 }
 
 func (e *BackpackExecutor) Close() {
@@ -153,9 +368,7 @@ func (e *BackpackExecutor) Close() {
 }
 
 // signedRequest makes an authenticated request to Backpack API
-func (e *BackpackExecutor) signedRequest(method, path, instruction string, params map[string]string) ([]byte, error) {
-	backpackCfg := e.config.GetBackpack()
-	
+func (e *BackpackExecutor) signedRequest(ctx context.Context, method, path, instruction string, params map[string]string) ([]byte, error) {
 	timestamp := time.Now().UnixMilli()
 	window := int64(5000)
 	
@@ -182,6 +395,9 @@ func (e *BackpackExecutor) signedRequest(method, path, instruction string, param
 	signatureB64 := base64.StdEncoding.EncodeToString(signature)
 	
 	// Build request
+	ctx, cancel := requestContext(ctx)
+	defer cancel()
+
 	var req *http.Request
 	var err error
 	
@@ -189,12 +405,21 @@ func (e *BackpackExecutor) signedRequest(method, path, instruction string, param
 	
 	if method == "GET" {
 		// For GET, params go in query string
-		url += "?" + buildQueryString(params)
-		req, err = http.NewRequest(method, url, nil)
+		if len(params) > 0 {
+			url += "?" + buildQueryString(params)
+		}
+		req, err = http.NewRequestWithContext(ctx, method, url, nil)
 	} else {
-		// For POST, params go in JSON body
-		jsonBody, _ := json.Marshal(params)
-		req, err = http.NewRequest(method, url, bytes.NewBuffer(jsonBody))
+		// For POST, params go in JSON body; clientId is a u32, not a string
+		body := make(map[string]interface{}, len(params))
+		for k, v := range params {
+			body[k] = v
+		}
+		if clientID, ok := params["clientId"]; ok {
+			body["clientId"], _ = strconv.ParseUint(clientID, 10, 32)
+		}
+		jsonBody, _ := json.Marshal(body)
+		req, err = http.NewRequestWithContext(ctx, method, url, bytes.NewBuffer(jsonBody))
 		req.Header.Set("Content-Type", "application/json")
 	}
 	
@@ -203,7 +428,7 @@ func (e *BackpackExecutor) signedRequest(method, path, instruction string, param
 	}
 	
 	// Set auth headers
-	req.Header.Set("X-API-Key", backpackCfg.APIKey)
+	req.Header.Set("X-API-Key", e.account.APIKey)
 	req.Header.Set("X-Signature", signatureB64)
 	req.Header.Set("X-Timestamp", strconv.FormatInt(timestamp, 10))
 	req.Header.Set("X-Window", strconv.FormatInt(window, 10))
@@ -234,16 +459,3 @@ func buildQueryString(params map[string]string) string {
 	}
 	return strings.Join(parts, "&")
 }
-
-// convertSymbol converts BTCUSDT to BTC_USDT format
-func convertSymbol(symbol string) string {
-	// Common patterns: BTCUSDT -> BTC_USDT, SOLUSDC -> SOL_USDC
-	quotes := []string{"USDT", "USDC", "USD", "BTC", "ETH"}
-	for _, quote := range quotes {
-		if strings.HasSuffix(symbol, quote) {
-			base := strings.TrimSuffix(symbol, quote)
-			return base + "_" + quote
-		}
-	}
-	return symbol
-}
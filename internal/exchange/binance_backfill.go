@@ -0,0 +1,259 @@
+package exchange
+
+import (
+	"context"
+	"crypto-sync-bot/internal/config"
+	"crypto-sync-bot/internal/database"
+	"crypto-sync-bot/internal/models"
+	"crypto-sync-bot/internal/processor"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+const (
+	// binanceBackfillWindow caps how far back a backfill looks. Older fills are
+	// past the idempotency TTL and too stale to mirror anyway.
+	binanceBackfillWindow = 24 * time.Hour
+	binanceTradePageLimit = 1000
+)
+
+// loadCheckpoint restores the last processed fill. On the very first run there
+// is nothing to recover, so the checkpoint starts at the current time.
+func (b *BinanceListener) loadCheckpoint() {
+	cp, err := database.LoadCheckpoint(b.account.ID)
+	if err != nil {
+		log.Printf("Warning: Failed to load Binance checkpoint: %v", err)
+	}
+
+	b.checkpointMu.Lock()
+	defer b.checkpointMu.Unlock()
+	if cp != nil {
+		b.checkpoint = *cp
+		return
+	}
+	b.checkpoint = database.ListenerCheckpoint{
+		Source:        b.account.ID,
+		LastEventTime: time.Now().UnixMilli(),
+	}
+	if err := database.SaveCheckpoint(&b.checkpoint); err != nil {
+		log.Printf("Warning: Failed to save Binance checkpoint: %v", err)
+	}
+}
+
+// advanceCheckpoint records a processed fill; it never moves the checkpoint
+// backwards. While a backfill runs, the latest fill is held back instead of
+// saved: a live fill can be newer than gap fills not yet replayed, and a crash
+// must not resume past them.
+func (b *BinanceListener) advanceCheckpoint(tradeTime, tradeID int64) {
+	b.checkpointMu.Lock()
+	defer b.checkpointMu.Unlock()
+	if b.backfilling {
+		if tradeTime >= b.held.LastEventTime {
+			b.held.LastEventTime, b.held.LastTradeID = tradeTime, tradeID
+		}
+		return
+	}
+	b.saveCheckpoint(tradeTime, tradeID)
+}
+
+// saveCheckpoint moves the checkpoint forward and persists it; callers hold checkpointMu
+func (b *BinanceListener) saveCheckpoint(tradeTime, tradeID int64) {
+	if tradeTime < b.checkpoint.LastEventTime {
+		return
+	}
+	b.checkpoint.LastEventTime = tradeTime
+	b.checkpoint.LastTradeID = tradeID
+	if err := database.SaveCheckpoint(&b.checkpoint); err != nil {
+		log.Printf("Warning: Failed to save Binance checkpoint: %v", err)
+	}
+}
+
+// holdCheckpoint stops checkpoint writes until the returned release is called,
+// which saves the latest fill seen in between
+func (b *BinanceListener) holdCheckpoint() (release func()) {
+	b.checkpointMu.Lock()
+	b.backfilling = true
+	b.held = database.ListenerCheckpoint{}
+	b.checkpointMu.Unlock()
+
+	return func() {
+		b.checkpointMu.Lock()
+		defer b.checkpointMu.Unlock()
+		b.backfilling = false
+		if b.held.LastEventTime > 0 {
+			b.saveCheckpoint(b.held.LastEventTime, b.held.LastTradeID)
+		}
+	}
+}
+
+// backfill replays fills that happened since the last checkpoint using the REST
+// user trades and order endpoints. Replayed signals carry the same IDs,
+// quantities and prices as live ones, so the processor's idempotency keys drop
+// anything that was already mirrored.
+func (b *BinanceListener) backfill() {
+	b.checkpointMu.Lock()
+	since, lastTradeID := b.checkpoint.LastEventTime, b.checkpoint.LastTradeID
+	b.checkpointMu.Unlock()
+	release := b.holdCheckpoint()
+	defer release()
+
+	if floor := time.Now().Add(-binanceBackfillWindow).UnixMilli(); since < floor {
+		log.Printf("Binance checkpoint is older than %s, backfilling from %s only", binanceBackfillWindow, time.UnixMilli(floor).Format(time.RFC3339))
+		since = floor
+	}
+
+	for _, symbol := range b.backfillSymbols(since) {
+		replayed, err := b.backfillSymbol(symbol, since, lastTradeID)
+		if err != nil {
+			log.Printf("Error backfilling Binance %s: %v", symbol, err)
+			continue
+		}
+		if replayed > 0 {
+			log.Printf("Backfilled %d Binance %s fill(s) since %s", replayed, symbol, time.UnixMilli(since).Format(time.RFC3339))
+		}
+	}
+}
+
+// backfillSymbols returns the symbols to backfill. User trades can only be
+// listed per symbol, so for wildcard sync items the symbols traded since the
+// checkpoint are discovered from commission income, which every fill incurs.
+func (b *BinanceListener) backfillSymbols(since int64) []models.Symbol {
+	all, symbols := b.symbols()
+	if !all {
+		return symbols
+	}
+
+	seen := make(map[models.Symbol]bool)
+	for _, symbol := range symbols {
+		seen[symbol] = true
+	}
+	incomes, err := b.commissionsSince(since)
+	if err != nil {
+		log.Printf("Error discovering traded Binance symbols: %v", err)
+	}
+	for _, income := range incomes {
+		symbol, err := binanceSymbols{}.FromExchange(income.Symbol)
+		if err != nil || seen[symbol] {
+			continue
+		}
+		seen[symbol] = true
+		symbols = append(symbols, symbol)
+	}
+	return symbols
+}
+
+// commissionsSince pages through commission income starting at since
+// (milliseconds). On error it returns the pages fetched so far.
+func (b *BinanceListener) commissionsSince(since int64) ([]*futures.IncomeHistory, error) {
+	var incomes []*futures.IncomeHistory
+	for {
+		page, err := b.client.NewGetIncomeHistoryService().
+			IncomeType("COMMISSION").
+			StartTime(since).
+			Limit(binanceTradePageLimit).
+			Do(context.Background())
+		if err != nil {
+			return incomes, err
+		}
+		incomes = append(incomes, page...)
+		if len(page) < binanceTradePageLimit {
+			return incomes, nil
+		}
+
+		// Income can only be paged by time. The next page starts at the last
+		// timestamp, so entries sharing it are not lost, unless a whole page
+		// shares one timestamp and starting there would loop.
+		last := page[len(page)-1].Time
+		if last <= since {
+			last = since + 1
+		}
+		since = last
+	}
+}
+
+func (b *BinanceListener) backfillSymbol(symbol models.Symbol, since, lastTradeID int64) (int, error) {
+	native, err := binanceSymbols{}.ToExchange(symbol)
+	if err != nil {
+		return 0, err
+	}
+	trades, err := b.userTradesSince(native, since)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list user trades: %w", err)
+	}
+
+	incremental := b.account.FillMode == config.FillModeIncremental
+	orders := make(map[int64]*futures.Order)
+	emitted := make(map[int64]bool)
+	replayed := 0
+
+	for _, trade := range trades {
+		if trade.Time == since && trade.ID == lastTradeID {
+			continue
+		}
+
+		order, ok := orders[trade.OrderID]
+		if !ok {
+			order, err = b.client.NewGetOrderService().Symbol(native).OrderID(trade.OrderID).Do(context.Background())
+			if err != nil {
+				return replayed, fmt.Errorf("failed to get order %d: %w", trade.OrderID, err)
+			}
+			orders[trade.OrderID] = order
+		}
+
+		var signal *models.TradingSignal
+		var parseErr error
+		if incremental {
+			signalID := fmt.Sprintf("%d-%d", trade.OrderID, trade.ID)
+			signal, parseErr = b.newSignal(signalID, symbol, string(trade.Side), string(order.Type), trade.Quantity, trade.Price, trade.Time)
+		} else if order.Status == futures.OrderStatusTypeFilled && !emitted[order.OrderID] {
+			// Filled mode mirrors the whole order once, like the live FILLED update
+			emitted[order.OrderID] = true
+			signalID := strconv.FormatInt(order.OrderID, 10)
+			signal, parseErr = b.newSignal(signalID, symbol, string(order.Side), string(order.Type), order.ExecutedQuantity, order.AvgPrice, order.UpdateTime)
+		}
+		if parseErr != nil {
+			log.Printf("Error parsing Binance trade %d: %v", trade.ID, parseErr)
+		} else if signal != nil {
+			// The same source order ID as the live stream, so fills of orders
+			// mirrored while resting are recognised and limit copies are linked
+			signal.SourceOrderID = strconv.FormatInt(trade.OrderID, 10)
+			if err := processor.ProduceSignal(context.Background(), signal); err != nil {
+				return replayed, fmt.Errorf("failed to produce signal: %w", err)
+			}
+			replayed++
+		}
+		b.advanceCheckpoint(trade.Time, trade.ID)
+	}
+	return replayed, nil
+}
+
+// userTradesSince pages through account trades starting at since (milliseconds)
+func (b *BinanceListener) userTradesSince(native string, since int64) ([]*futures.AccountTrade, error) {
+	page, err := b.client.NewListAccountTradeService().
+		Symbol(native).
+		StartTime(since).
+		Limit(binanceTradePageLimit).
+		Do(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	trades := page
+	for len(page) == binanceTradePageLimit {
+		// fromId cannot be combined with startTime, so later pages continue by ID
+		page, err = b.client.NewListAccountTradeService().
+			Symbol(native).
+			FromID(page[len(page)-1].ID + 1).
+			Limit(binanceTradePageLimit).
+			Do(context.Background())
+		if err != nil {
+			return nil, err
+		}
+		trades = append(trades, page...)
+	}
+	return trades, nil
+}
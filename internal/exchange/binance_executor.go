@@ -0,0 +1,320 @@
+package exchange
+
+import (
+	"context"
+	"crypto-sync-bot/internal/config"
+	"crypto-sync-bot/internal/models"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+
+	"github.com/adshao/go-binance/v2/common"
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+const (
+	binanceFuturesURL        = "https://fapi.binance.com"
+	binanceFuturesTestnetURL = "https://testnet.binancefuture.com"
+
+	binanceErrOrderNotFound = -2013 // "Order does not exist."
+)
+
+func init() {
+	RegisterExecutor("binance", func(account config.AccountConfig) (models.ExchangeExecutor, error) {
+		if account.APIKey == "" {
+			return nil, nil
+		}
+		return NewBinanceExecutor(account), nil
+	})
+}
+
+// BinanceExecutor places orders on Binance USDⓈ-M futures, so Binance can be
+// a copy target as well as the lead account watched by BinanceListener.
+type BinanceExecutor struct {
+	client  *futures.Client
+	account config.AccountConfig
+}
+
+func NewBinanceExecutor(account config.AccountConfig) *BinanceExecutor {
+	return &BinanceExecutor{
+		client:  newBinanceFuturesClient(account),
+		account: account,
+	}
+}
+
+// newBinanceFuturesClient creates a client pointed at mainnet or testnet without
+// touching the package-level futures.UseTestnet flag.
+func newBinanceFuturesClient(account config.AccountConfig) *futures.Client {
+	client := futures.NewClient(account.APIKey, account.APISecret)
+	if account.Testnet {
+		client.BaseURL = binanceFuturesTestnetURL
+	} else {
+		client.BaseURL = binanceFuturesURL
+	}
+	return client
+}
+
+func (e *BinanceExecutor) Name() string {
+	return "Binance"
+}
+
+func (e *BinanceExecutor) PlaceOrder(ctx context.Context, signal *models.TradingSignal) (*models.OrderResult, error) {
+	ctx, cancel := requestContext(ctx)
+	defer cancel()
+
+	failed := func(err error) (*models.OrderResult, error) {
+		log.Printf("Binance Order Failed: %v", err)
+		return &models.OrderResult{
+			Exchange:     "Binance",
+			Symbol:       signal.Symbol,
+			Status:       "failed",
+			ErrorMessage: err.Error(),
+			Timestamp:    signal.Timestamp,
+		}, err
+	}
+
+	symbol, err := nativeSymbol(binanceSymbols{}, signal.Symbol)
+	if err != nil {
+		return failed(err)
+	}
+
+	side := futures.SideTypeBuy
+	if signal.Side == "SELL" {
+		side = futures.SideTypeSell
+	}
+
+	service := e.client.NewCreateOrderService().
+		Symbol(symbol).
+		Side(side).
+		Quantity(formatDecimal(signal.Quantity))
+	if signal.ClientOrderID != "" {
+		service = service.NewClientOrderID(signal.ClientOrderID)
+	}
+
+	if signal.OrderType == "LIMIT" {
+		service = service.Type(futures.OrderTypeLimit).
+			TimeInForce(futures.TimeInForceTypeGTC).
+			Price(formatDecimal(signal.Price))
+	} else {
+		service = service.Type(futures.OrderTypeMarket)
+	}
+
+	res, err := service.Do(ctx)
+	if err != nil {
+		return failed(err)
+	}
+
+	return &models.OrderResult{
+		Exchange:  "Binance",
+		Symbol:    signal.Symbol,
+		Status:    "success",
+		OrderID:   strconv.FormatInt(res.OrderID, 10),
+		Timestamp: signal.Timestamp,
+	}, nil
+}
+
+// SupportsConditionalOrders reports that stop-loss and take-profit are not
+// attached: Binance futures needs them as separate STOP_MARKET and
+// TAKE_PROFIT_MARKET orders, which are not placed here
+func (e *BinanceExecutor) SupportsConditionalOrders() bool {
+	return false
+}
+
+func (e *BinanceExecutor) GetOrder(ctx context.Context, orderID, symbol string) (*models.OrderResult, error) {
+	ctx, cancel := requestContext(ctx)
+	defer cancel()
+
+	native, err := nativeSymbol(binanceSymbols{}, symbol)
+	if err != nil {
+		return nil, err
+	}
+	id, err := strconv.ParseInt(orderID, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid binance order id %q: %w", orderID, err)
+	}
+
+	order, err := e.client.NewGetOrderService().Symbol(native).OrderID(id).Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return binanceOrderResult(order, symbol), nil
+}
+
+// GetOrderByClientID looks up an order by the client order ID it was placed with
+func (e *BinanceExecutor) GetOrderByClientID(ctx context.Context, clientOrderID, symbol string) (*models.OrderResult, error) {
+	ctx, cancel := requestContext(ctx)
+	defer cancel()
+
+	native, err := nativeSymbol(binanceSymbols{}, symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	order, err := e.client.NewGetOrderService().Symbol(native).OrigClientOrderID(clientOrderID).Do(ctx)
+	if err != nil {
+		var apiErr *common.APIError
+		if errors.As(err, &apiErr) && apiErr.Code == binanceErrOrderNotFound {
+			return nil, fmt.Errorf("binance order %s: %w", clientOrderID, models.ErrOrderNotFound)
+		}
+		return nil, err
+	}
+	return binanceOrderResult(order, symbol), nil
+}
+
+func (e *BinanceExecutor) CancelOrder(ctx context.Context, orderID, symbol string) error {
+	_, err := e.cancelOrder(ctx, orderID, symbol)
+	return err
+}
+
+// AmendOrder cancels and replaces the order, as the client library has no
+// order modification
+func (e *BinanceExecutor) AmendOrder(ctx context.Context, orderID string, signal *models.TradingSignal) (*models.OrderResult, error) {
+	cancelled, err := e.cancelOrder(ctx, orderID, signal.Symbol)
+	if err != nil {
+		return nil, err
+	}
+	filled, _ := strconv.ParseFloat(cancelled.ExecutedQuantity, 64)
+	return replaceOrder(ctx, e, orderID, signal, filled)
+}
+
+func (e *BinanceExecutor) cancelOrder(ctx context.Context, orderID, symbol string) (*futures.CancelOrderResponse, error) {
+	ctx, cancel := requestContext(ctx)
+	defer cancel()
+
+	native, err := nativeSymbol(binanceSymbols{}, symbol)
+	if err != nil {
+		return nil, err
+	}
+	id, err := strconv.ParseInt(orderID, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid binance order id %q: %w", orderID, err)
+	}
+	return e.client.NewCancelOrderService().Symbol(native).OrderID(id).Do(ctx)
+}
+
+func binanceOrderResult(order *futures.Order, symbol string) *models.OrderResult {
+	result := &models.OrderResult{
+		Exchange: "Binance",
+		Symbol:   symbol,
+		OrderID:  strconv.FormatInt(order.OrderID, 10),
+		Status:   mapBinanceOrderStatus(order.Status),
+	}
+	result.FilledQuantity, _ = strconv.ParseFloat(order.ExecutedQuantity, 64)
+	result.AvgPrice, _ = strconv.ParseFloat(order.AvgPrice, 64)
+	return result
+}
+
+func (e *BinanceExecutor) GetBalance(ctx context.Context, asset string) (float64, error) {
+	return binanceFuturesEquity(ctx, e.client, asset)
+}
+
+func (e *BinanceExecutor) GetPosition(ctx context.Context, symbol string) (*models.Position, error) {
+	return binanceFuturesPosition(ctx, e.client, symbol)
+}
+
+func (e *BinanceExecutor) GetInstrument(ctx context.Context, symbol string) (*models.Instrument, error) {
+	ctx, cancel := requestContext(ctx)
+	defer cancel()
+
+	native, err := nativeSymbol(binanceSymbols{}, symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := e.client.NewExchangeInfoService().Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range info.Symbols {
+		s := &info.Symbols[i]
+		if s.Symbol != native {
+			continue
+		}
+		instrument := &models.Instrument{Symbol: symbol}
+		if f := s.LotSizeFilter(); f != nil {
+			instrument.MinQty, _ = strconv.ParseFloat(f.MinQuantity, 64)
+			instrument.QtyStep, _ = strconv.ParseFloat(f.StepSize, 64)
+		}
+		if f := s.PriceFilter(); f != nil {
+			instrument.TickSize, _ = strconv.ParseFloat(f.TickSize, 64)
+		}
+		if f := s.MinNotionalFilter(); f != nil {
+			instrument.MinNotional, _ = strconv.ParseFloat(f.Notional, 64)
+		}
+		return instrument, nil
+	}
+	return nil, fmt.Errorf("binance instrument %s not found", native)
+}
+
+func (e *BinanceExecutor) Close() {
+	// Cleanup if needed
+}
+
+// binanceFuturesEquity returns the futures account equity (wallet balance plus unrealized PnL) in asset
+func binanceFuturesEquity(ctx context.Context, client *futures.Client, asset string) (float64, error) {
+	ctx, cancel := requestContext(ctx)
+	defer cancel()
+
+	balances, err := client.NewGetBalanceService().Do(ctx)
+	if err != nil {
+		return 0, err
+	}
+	for _, balance := range balances {
+		if balance.Asset != asset {
+			continue
+		}
+		wallet, err := parseFloat(balance.Balance)
+		if err != nil {
+			return 0, err
+		}
+		upnl, err := parseFloat(balance.CrossUnPnl)
+		if err != nil {
+			return 0, err
+		}
+		return wallet + upnl, nil
+	}
+	return 0, nil
+}
+
+// binanceFuturesPosition returns the net position in symbol. In hedge mode the
+// LONG and SHORT legs are summed; positionAmt is signed in both modes.
+func binanceFuturesPosition(ctx context.Context, client *futures.Client, symbol string) (*models.Position, error) {
+	ctx, cancel := requestContext(ctx)
+	defer cancel()
+
+	native, err := nativeSymbol(binanceSymbols{}, symbol)
+	if err != nil {
+		return nil, err
+	}
+	risks, err := client.NewGetPositionRiskService().Symbol(native).Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	position := &models.Position{Symbol: symbol}
+	for _, risk := range risks {
+		amount, err := parseFloat(risk.PositionAmt)
+		if err != nil {
+			return nil, err
+		}
+		if amount == 0 {
+			continue
+		}
+		position.Size += amount
+		position.EntryPrice, _ = strconv.ParseFloat(risk.EntryPrice, 64)
+	}
+	return position, nil
+}
+
+// mapBinanceOrderStatus maps Binance futures order statuses to the statuses used in the orders table
+func mapBinanceOrderStatus(status futures.OrderStatusType) string {
+	switch status {
+	case futures.OrderStatusTypeCanceled, futures.OrderStatusTypeExpired:
+		return "CANCELLED"
+	default:
+		// NEW, PARTIALLY_FILLED, FILLED and REJECTED already match
+		return string(status)
+	}
+}
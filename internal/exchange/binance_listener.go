@@ -3,32 +3,67 @@ package exchange
 import (
 	"context"
 	"crypto-sync-bot/internal/config"
+	"crypto-sync-bot/internal/database"
 	"crypto-sync-bot/internal/models"
 	"crypto-sync-bot/internal/processor"
+	"encoding/json"
 	"fmt"
 	"log"
+	"net/http"
 	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/adshao/go-binance/v2/futures"
+	"github.com/gorilla/websocket"
+)
+
+func init() {
+	RegisterListener(config.ExchangeBinance, func(account config.AccountConfig, cfg *config.Config) (Listener, error) {
+		if account.APIKey == "" {
+			return nil, nil
+		}
+		return NewBinanceListener(account, cfg), nil
+	})
+}
+
+// binanceExecutionAmendment is the execution type of an order whose price or
+// quantity was modified; the client library has no constant for it
+const binanceExecutionAmendment futures.OrderExecutionType = "AMENDMENT"
+
+const (
+	binanceFuturesWSURL        = "wss://fstream.binance.com/ws"
+	binanceFuturesTestnetWSURL = "wss://stream.binancefuture.com/ws"
 )
 
 type BinanceListener struct {
 	client   *futures.Client // Using Futures Client for API calls if needed
+	account  config.AccountConfig
 	config   *config.Config
 	mu       sync.Mutex
 	running  bool
 	stopChan chan struct{}
+
+	checkpointMu sync.Mutex
+	checkpoint   database.ListenerCheckpoint
+	backfilling  bool                        // checkpoint writes are held while the gap is replayed
+	held         database.ListenerCheckpoint // latest fill seen while backfilling
 }
 
-func NewBinanceListener(cfg *config.Config) *BinanceListener {
+func NewBinanceListener(account config.AccountConfig, cfg *config.Config) *BinanceListener {
 	return &BinanceListener{
+		account:  account,
 		config:   cfg,
 		stopChan: make(chan struct{}),
 	}
 }
 
+func (b *BinanceListener) Name() string {
+	return "Binance"
+}
+
 func (b *BinanceListener) Start() error {
 	b.mu.Lock()
 	if b.running {
@@ -38,14 +73,9 @@ func (b *BinanceListener) Start() error {
 	b.running = true
 	b.mu.Unlock()
 
-	// Configure Testnet if needed
-	binanceCfg := b.config.GetBinance()
-	if binanceCfg.Testnet {
-		futures.UseTestnet = true
-	}
-
 	// Initialize the client (optional, mostly for REST calls)
-	b.client = futures.NewClient(binanceCfg.APIKey, binanceCfg.APISecret)
+	b.client = newBinanceFuturesClient(b.account)
+	b.loadCheckpoint()
 
 	go b.connectWebSocket()
 
@@ -88,35 +118,9 @@ func (b *BinanceListener) connectWebSocket() {
 				}
 			}(listenKey)
 
-			doneC, stopC, err := futures.WsUserDataServe(listenKey, func(event *futures.WsUserDataEvent) {
-				if event.Event == "ORDER_TRADE_UPDATE" {
-					trade := event.OrderTradeUpdate
-					if trade.Status == "FILLED" && trade.Symbol == b.config.GetSync().Symbol {
-						qty, err := parseFloat(trade.AccumulatedFilledQty)
-						if err != nil {
-							log.Printf("Error parsing Quantity from Binance: %v", err)
-							return
-						}
-						price, err := parseFloat(trade.AveragePrice)
-						if err != nil {
-							log.Printf("Error parsing Price from Binance: %v", err)
-							return
-						}
-
-						signal := &models.TradingSignal{
-							SignalID:  strconv.FormatInt(trade.ID, 10),
-							Symbol:    trade.Symbol,
-							Side:      string(trade.Side),
-							OrderType: string(trade.Type),
-							Quantity:  qty,
-							Price:     price,
-							Timestamp: event.Time,
-							Source:    "binance",
-						}
-						if err := processor.ProduceSignal(context.Background(), signal); err != nil {
-							log.Printf("Error producing signal from Binance: %v", err)
-						}
-					}
+			doneC, stopC, err := binanceUserDataServe(b.wsURL()+"/"+listenKey, func(event *futures.WsUserDataEvent) {
+				if event.Event == futures.UserDataEventTypeOrderTradeUpdate {
+					b.handleOrderTradeUpdate(event)
 				}
 			}, errHandler)
 
@@ -126,6 +130,10 @@ func (b *BinanceListener) connectWebSocket() {
 				continue
 			}
 
+			// Subscribe first, then recover anything missed while disconnected;
+			// fills seen by both paths are dropped by the processor's idempotency check
+			b.backfill()
+
 			<-doneC
 			close(stopC)
 			log.Println("Binance WebSocket disconnected, reconnecting...")
@@ -134,6 +142,285 @@ func (b *BinanceListener) connectWebSocket() {
 	}
 }
 
+// wsURL returns the user data stream endpoint of the account's network.
+// futures.WsUserDataServe follows the package-wide futures.UseTestnet flag
+// instead, which would move every Binance listener in the process at once.
+func (b *BinanceListener) wsURL() string {
+	if b.account.Testnet {
+		return binanceFuturesTestnetWSURL
+	}
+	return binanceFuturesWSURL
+}
+
+// binanceUserDataServe streams user data events from endpoint like
+// futures.WsUserDataServe: doneC is closed when the connection drops, and
+// closing stopC disconnects.
+func binanceUserDataServe(endpoint string, handler func(event *futures.WsUserDataEvent), errHandler func(err error)) (doneC, stopC chan struct{}, err error) {
+	dialer := websocket.Dialer{
+		Proxy:            http.ProxyFromEnvironment,
+		HandshakeTimeout: 45 * time.Second,
+	}
+	conn, _, err := dialer.Dial(endpoint, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	doneC = make(chan struct{})
+	stopC = make(chan struct{})
+	go func() {
+		defer close(doneC)
+		var stopped atomic.Bool
+		go func() {
+			select {
+			case <-stopC:
+				stopped.Store(true)
+			case <-doneC:
+			}
+			conn.Close()
+		}()
+		for {
+			_, message, err := conn.ReadMessage()
+			if err != nil {
+				if !stopped.Load() {
+					errHandler(err)
+				}
+				return
+			}
+			event := new(futures.WsUserDataEvent)
+			if err := json.Unmarshal(message, event); err != nil {
+				errHandler(err)
+				continue
+			}
+			handler(event)
+		}
+	}()
+	return doneC, stopC, nil
+}
+
+// handleOrderTradeUpdate turns an order update into a signal. New limit
+// orders, cancels and amendments become order change signals; fills are
+// mirrored according to the configured fill mode. In filled mode one signal carries the whole order once it
+// is FILLED; in incremental mode every execution emits the newly filled quantity,
+// so partial fills and partially filled cancels are mirrored as they happen.
+func (b *BinanceListener) handleOrderTradeUpdate(event *futures.WsUserDataEvent) {
+	trade := event.OrderTradeUpdate
+	incremental := b.account.FillMode == config.FillModeIncremental
+
+	switch {
+	case trade.ExecutionType == futures.OrderExecutionTypeNew && trade.Type == futures.OrderTypeLimit:
+		if err := b.produceOrderChange(event, models.ActionPlace); err != nil {
+			log.Printf("Dropping Binance order update: %v", err)
+		}
+		return
+	case trade.ExecutionType == binanceExecutionAmendment:
+		if err := b.produceOrderChange(event, models.ActionAmend); err != nil {
+			log.Printf("Dropping Binance order update: %v", err)
+		}
+		return
+	case trade.Status == futures.OrderStatusTypeCanceled || trade.Status == futures.OrderStatusTypeExpired:
+		if err := b.produceOrderChange(event, models.ActionCancel); err != nil {
+			log.Printf("Dropping Binance order update: %v", err)
+		}
+		return
+	}
+
+	if incremental {
+		if trade.ExecutionType != futures.OrderExecutionTypeTrade {
+			return
+		}
+	} else if trade.Status != futures.OrderStatusTypeFilled {
+		return
+	}
+
+	symbol, err := binanceSymbols{}.FromExchange(trade.Symbol)
+	if err != nil {
+		log.Printf("Ignoring Binance fill with unknown symbol: %v", err)
+		return
+	}
+	if !b.watches(symbol) {
+		return
+	}
+
+	signalID := strconv.FormatInt(trade.ID, 10)
+	qty, price := trade.AccumulatedFilledQty, trade.AveragePrice
+	if incremental {
+		// Trade IDs are unique per symbol, so order+trade ID never repeats
+		signalID = fmt.Sprintf("%d-%d", trade.ID, trade.TradeID)
+		qty, price = trade.LastFilledQty, trade.LastFilledPrice
+	}
+
+	signal, err := b.newSignal(signalID, symbol, string(trade.Side), string(trade.Type), qty, price, event.Time)
+	if err != nil {
+		log.Printf("Error parsing Binance fill: %v", err)
+		return
+	}
+	if signal == nil {
+		return
+	}
+	signal.SourceOrderID = strconv.FormatInt(trade.ID, 10)
+	if err := processor.ProduceSignal(context.Background(), signal); err != nil {
+		log.Printf("Error producing signal from Binance: %v", err)
+		return
+	}
+	b.advanceCheckpoint(trade.TradeTime, trade.TradeID)
+}
+
+// produceOrderChange emits a new resting order, cancel or amendment of a
+// source order. Placements are copied by the sync items that mirror orders;
+// cancels and amendments apply to the target orders linked to the source
+// order. Placements and amendments carry the price and total quantity; the
+// event is dropped with an error if those cannot be read.
+func (b *BinanceListener) produceOrderChange(event *futures.WsUserDataEvent, action string) error {
+	trade := event.OrderTradeUpdate
+	symbol, err := binanceSymbols{}.FromExchange(trade.Symbol)
+	if err != nil {
+		log.Printf("Ignoring Binance order update with unknown symbol: %v", err)
+		return nil
+	}
+	if !b.watches(symbol) {
+		return nil
+	}
+	if action == models.ActionPlace && !b.mirrorsOrders(symbol) {
+		return nil
+	}
+
+	// One placement and cancel per order; an order may be amended many times
+	var signalID string
+	switch action {
+	case models.ActionPlace:
+		signalID = fmt.Sprintf("%d-new", trade.ID)
+	case models.ActionCancel:
+		signalID = fmt.Sprintf("%d-cancel", trade.ID)
+	default:
+		signalID = fmt.Sprintf("%d-amend-%d", trade.ID, event.Time)
+	}
+
+	signal := &models.TradingSignal{
+		SignalID:      signalID,
+		Symbol:        symbol.String(),
+		Side:          string(trade.Side),
+		OrderType:     string(trade.Type),
+		Timestamp:     event.Time,
+		Source:        b.account.ID,
+		Action:        action,
+		SourceOrderID: strconv.FormatInt(trade.ID, 10),
+	}
+	// A cancel needs no terms; a placement or amendment with unreadable ones
+	// would size the copy to zero, so it is dropped
+	if action != models.ActionCancel {
+		if signal.Quantity, err = parseFloat(trade.OriginalQty); err != nil {
+			return fmt.Errorf("%s of order %d: quantity: %w", action, trade.ID, err)
+		}
+		if signal.Price, err = parseFloat(trade.OriginalPrice); err != nil {
+			return fmt.Errorf("%s of order %d: price: %w", action, trade.ID, err)
+		}
+	}
+	if err := processor.ProduceSignal(context.Background(), signal); err != nil {
+		return fmt.Errorf("failed to produce %s signal: %w", action, err)
+	}
+	return nil
+}
+
+// watches reports whether fills on symbol should be mirrored. Sync items are
+// read on every call, so changes made through the API apply immediately.
+func (b *BinanceListener) watches(symbol models.Symbol) bool {
+	all, symbols := b.symbols()
+	if all {
+		return true
+	}
+	for _, wanted := range symbols {
+		if symbol == wanted {
+			return true
+		}
+	}
+	return false
+}
+
+// mirrorsOrders reports whether an enabled sync item sourced from this account
+// copies resting limit orders in symbol
+func (b *BinanceListener) mirrorsOrders(symbol models.Symbol) bool {
+	for _, item := range b.config.GetSyncItems() {
+		if !item.Enabled || !item.MirrorsOrders() || !strings.EqualFold(item.Source, b.account.ID) {
+			continue
+		}
+		if item.AllSymbols() {
+			return true
+		}
+		if parsed, err := models.ParseSymbol(item.Symbol); err == nil && parsed == symbol {
+			return true
+		}
+	}
+	return false
+}
+
+// symbols returns the union of symbols across enabled sync items sourced from
+// this account. all is true when any of those items mirrors every symbol.
+func (b *BinanceListener) symbols() (all bool, symbols []models.Symbol) {
+	seen := make(map[models.Symbol]bool)
+	for _, item := range b.config.GetSyncItems() {
+		if !item.Enabled || !strings.EqualFold(item.Source, b.account.ID) {
+			continue
+		}
+		if item.AllSymbols() {
+			all = true
+			continue
+		}
+		symbol, err := models.ParseSymbol(item.Symbol)
+		if err != nil {
+			log.Printf("Invalid symbol %q in sync item %s: %v", item.Symbol, item.ID, err)
+			continue
+		}
+		if !seen[symbol] {
+			seen[symbol] = true
+			symbols = append(symbols, symbol)
+		}
+	}
+	return all, symbols
+}
+
+// newSignal builds a signal from Binance string fields.
+// It returns nil when nothing was filled.
+func (b *BinanceListener) newSignal(signalID string, symbol models.Symbol, side, orderType, qtyField, priceField string, timestamp int64) (*models.TradingSignal, error) {
+	qty, err := parseFloat(qtyField)
+	if err != nil {
+		return nil, fmt.Errorf("quantity: %w", err)
+	}
+	if qty <= 0 {
+		return nil, nil
+	}
+	price, err := parseFloat(priceField)
+	if err != nil {
+		return nil, fmt.Errorf("price: %w", err)
+	}
+
+	return &models.TradingSignal{
+		SignalID:  signalID,
+		Symbol:    symbol.String(),
+		Side:      side,
+		OrderType: orderType,
+		Quantity:  qty,
+		Price:     price,
+		Timestamp: timestamp,
+		Source:    b.account.ID,
+	}, nil
+}
+
+// GetBalance returns the futures account equity (wallet balance plus unrealized PnL) in asset
+func (b *BinanceListener) GetBalance(ctx context.Context, asset string) (float64, error) {
+	if b.client == nil {
+		return 0, fmt.Errorf("binance listener not started")
+	}
+	return binanceFuturesEquity(ctx, b.client, asset)
+}
+
+// GetPosition returns the net futures position in symbol
+func (b *BinanceListener) GetPosition(ctx context.Context, symbol string) (*models.Position, error) {
+	if b.client == nil {
+		return nil, fmt.Errorf("binance listener not started")
+	}
+	return binanceFuturesPosition(ctx, b.client, symbol)
+}
+
 func (b *BinanceListener) Stop() {
 	close(b.stopChan)
 }
@@ -1,35 +1,66 @@
 package exchange
 
 import (
+	"context"
 	"crypto-sync-bot/internal/config"
 	"crypto-sync-bot/internal/models"
 	"fmt"
 	"log"
+	"net/http"
+	"strconv"
 
 	"github.com/hirokisan/bybit/v2"
 )
 
+func init() {
+	RegisterExecutor("bybit", func(account config.AccountConfig) (models.ExchangeExecutor, error) {
+		if account.APIKey == "" {
+			return nil, nil
+		}
+		return NewBybitExecutor(account), nil
+	})
+}
+
 type BybitExecutor struct {
 	client *bybit.Client
-	config *config.Config
+	account config.AccountConfig
 }
 
-func NewBybitExecutor(cfg *config.Config) *BybitExecutor {
-	bybitCfg := cfg.GetBybit()
-	client := bybit.NewClient().
-		WithAuth(bybitCfg.APIKey, bybitCfg.APISecret)
+func NewBybitExecutor(account config.AccountConfig) *BybitExecutor {
+	client := newBybitClient(account)
 	
 	return &BybitExecutor{
 		client: client,
-		config: cfg,
+		account: account,
 	}
 }
 
+// newBybitClient creates a REST client pointed at mainnet or testnet
+func newBybitClient(account config.AccountConfig) *bybit.Client {
+	client := bybit.NewClient().WithAuth(account.APIKey, account.APISecret)
+	if account.Testnet {
+		client = client.WithBaseURL(bybit.TestNetBaseURL)
+	}
+	return client
+}
+
+// bybitClient returns a copy of client whose requests are bound to ctx, as
+// the library takes no context. The cancel func must be called when done.
+func bybitClient(ctx context.Context, client *bybit.Client) (*bybit.Client, context.CancelFunc) {
+	ctx, cancel := requestContext(ctx)
+	bound := *client
+	bound.WithHTTPClient(&http.Client{Transport: contextTransport{ctx: ctx}})
+	return &bound, cancel
+}
+
 func (e *BybitExecutor) Name() string {
 	return "Bybit"
 }
 
-func (e *BybitExecutor) PlaceOrder(signal *models.TradingSignal) (*models.OrderResult, error) {
+func (e *BybitExecutor) PlaceOrder(ctx context.Context, signal *models.TradingSignal) (*models.OrderResult, error) {
+	client, cancel := bybitClient(ctx, e.client)
+	defer cancel()
+
 	// Map Side
 	var side bybit.Side
 	if signal.Side == "BUY" {
@@ -46,24 +77,43 @@ func (e *BybitExecutor) PlaceOrder(signal *models.TradingSignal) (*models.OrderR
 		orderType = bybit.OrderTypeLimit
 	}
 
-	// Map Symbol (Bybit uses BTCUSDT usually for Linear)
-	symbolStr := bybit.SymbolV5(signal.Symbol) // Assuming signal.Symbol is like "BTCUSDT"
+	// Map Symbol (BTC-USDT -> BTCUSDT linear contract)
+	native, err := nativeSymbol(bybitSymbols{}, signal.Symbol)
+	if err != nil {
+		return &models.OrderResult{
+			Exchange:     "Bybit",
+			Symbol:       signal.Symbol,
+			Status:       "failed",
+			ErrorMessage: err.Error(),
+			Timestamp:    signal.Timestamp,
+		}, err
+	}
+	symbolStr := bybit.SymbolV5(native)
 
 	// Create Order
 	// Using Unified Margin or Linear Futures API
-	res, err := e.client.V5().Order().CreateOrder(bybit.V5CreateOrderParam{
+	res, err := client.V5().Order().CreateOrder(bybit.V5CreateOrderParam{
 		Category: bybit.CategoryV5Linear,
 		Symbol:   symbolStr,
 		Side:     bybit.Side(side),
 		OrderType: bybit.OrderType(orderType),
-		Qty:      fmt.Sprintf("%f", signal.Quantity),
+		Qty:      formatDecimal(signal.Quantity),
+		OrderLinkID: func() *string {
+			if signal.ClientOrderID != "" {
+				return &signal.ClientOrderID
+			}
+			return nil
+		}(),
 		Price:    func() *string {
 			if signal.OrderType == "LIMIT" {
-				p := fmt.Sprintf("%f", signal.Price)
+				p := formatDecimal(signal.Price)
 				return &p
 			}
 			return nil
 		}(),
+		// Attached to the position the order opens, triggered at market
+		StopLoss:   optionalPrice(signal.StopLossPrice),
+		TakeProfit: optionalPrice(signal.TakeProfitPrice),
 	})
 
 	if err != nil {
@@ -86,31 +136,239 @@ func (e *BybitExecutor) PlaceOrder(signal *models.TradingSignal) (*models.OrderR
 	}, nil
 }
 
-func (e *BybitExecutor) GetOrder(orderID, symbol string) (*models.OrderResult, error) {
-	// Use GetOpenOrders to check status (Note: Filled orders might move to History)
-	symbolStr := bybit.SymbolV5(symbol)
-	res, err := e.client.V5().Order().GetOpenOrders(bybit.V5GetOpenOrdersParam{
+// SupportsConditionalOrders reports that stop-loss and take-profit are attached on placement
+func (e *BybitExecutor) SupportsConditionalOrders() bool {
+	return true
+}
+
+// optionalPrice formats a price for an optional request field, nil when unset
+func optionalPrice(v float64) *string {
+	if v <= 0 {
+		return nil
+	}
+	s := formatDecimal(v)
+	return &s
+}
+
+func (e *BybitExecutor) GetOrder(ctx context.Context, orderID, symbol string) (*models.OrderResult, error) {
+	return e.findOrder(ctx, symbol, &orderID, nil)
+}
+
+// GetOrderByClientID looks up an order by the orderLinkId it was placed with
+func (e *BybitExecutor) GetOrderByClientID(ctx context.Context, clientOrderID, symbol string) (*models.OrderResult, error) {
+	return e.findOrder(ctx, symbol, nil, &clientOrderID)
+}
+
+func (e *BybitExecutor) CancelOrder(ctx context.Context, orderID, symbol string) error {
+	client, cancel := bybitClient(ctx, e.client)
+	defer cancel()
+
+	native, err := nativeSymbol(bybitSymbols{}, symbol)
+	if err != nil {
+		return err
+	}
+	_, err = client.V5().Order().CancelOrder(bybit.V5CancelOrderParam{
 		Category: bybit.CategoryV5Linear,
-		Symbol:   &symbolStr,
+		Symbol:   bybit.SymbolV5(native),
 		OrderID:  &orderID,
 	})
+	return err
+}
+
+// AmendOrder changes price and quantity in place; qty is the new total order quantity
+func (e *BybitExecutor) AmendOrder(ctx context.Context, orderID string, signal *models.TradingSignal) (*models.OrderResult, error) {
+	client, cancel := bybitClient(ctx, e.client)
+	defer cancel()
+
+	native, err := nativeSymbol(bybitSymbols{}, signal.Symbol)
 	if err != nil {
 		return nil, err
 	}
-	if len(res.Result.List) == 0 {
-		// If not in open orders, check history
-		// Note: GetHistory might be needed here if supported
-		return nil, fmt.Errorf("order not found in open orders")
+	qty, price := formatDecimal(signal.Quantity), formatDecimal(signal.Price)
+	res, err := client.V5().Order().AmendOrder(bybit.V5AmendOrderParam{
+		Category: bybit.CategoryV5Linear,
+		Symbol:   bybit.SymbolV5(native),
+		OrderID:  &orderID,
+		Qty:      &qty,
+		Price:    &price,
+	})
+	if err != nil {
+		return nil, err
 	}
-	order := res.Result.List[0]
 	return &models.OrderResult{
-		Exchange: "Bybit",
-		Symbol:   symbol,
-		OrderID:  order.OrderID,
-		Status:   string(order.OrderStatus),
+		Exchange:  "Bybit",
+		Symbol:    signal.Symbol,
+		Status:    "success",
+		OrderID:   res.Result.OrderID,
+		Timestamp: signal.Timestamp,
 	}, nil
 }
 
+func (e *BybitExecutor) findOrder(ctx context.Context, symbol string, orderID, orderLinkID *string) (*models.OrderResult, error) {
+	client, cancel := bybitClient(ctx, e.client)
+	defer cancel()
+
+	native, err := nativeSymbol(bybitSymbols{}, symbol)
+	if err != nil {
+		return nil, err
+	}
+	symbolStr := bybit.SymbolV5(native)
+
+	// Open orders first; filled and cancelled orders move to the order history
+	res, err := client.V5().Order().GetOpenOrders(bybit.V5GetOpenOrdersParam{
+		Category:    bybit.CategoryV5Linear,
+		Symbol:      &symbolStr,
+		OrderID:     orderID,
+		OrderLinkID: orderLinkID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(res.Result.List) == 0 {
+		res, err = client.V5().Order().GetHistoryOrders(bybit.V5GetHistoryOrdersParam{
+			Category:    bybit.CategoryV5Linear,
+			Symbol:      &symbolStr,
+			OrderID:     orderID,
+			OrderLinkID: orderLinkID,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(res.Result.List) == 0 {
+		id := orderID
+		if id == nil {
+			id = orderLinkID
+		}
+		return nil, fmt.Errorf("bybit order %s not in open orders or order history: %w", *id, models.ErrOrderNotFound)
+	}
+
+	order := res.Result.List[0]
+	result := &models.OrderResult{
+		Exchange:     "Bybit",
+		Symbol:       symbol,
+		OrderID:      order.OrderID,
+		Status:       mapBybitOrderStatus(order.OrderStatus),
+		ErrorMessage: order.RejectReason,
+	}
+	result.FilledQuantity, _ = strconv.ParseFloat(order.CumExecQty, 64)
+	result.AvgPrice, _ = strconv.ParseFloat(order.AvgPrice, 64)
+	if result.ErrorMessage == "EC_NoError" {
+		result.ErrorMessage = ""
+	}
+	return result, nil
+}
+
+func (e *BybitExecutor) GetBalance(ctx context.Context, asset string) (float64, error) {
+	return bybitEquity(ctx, e.client, asset)
+}
+
+// bybitEquity returns the unified account equity in asset
+func bybitEquity(ctx context.Context, client *bybit.Client, asset string) (float64, error) {
+	client, cancel := bybitClient(ctx, client)
+	defer cancel()
+
+	res, err := client.V5().Account().GetWalletBalance(bybit.AccountTypeV5UNIFIED, []bybit.Coin{bybit.Coin(asset)})
+	if err != nil {
+		return 0, err
+	}
+	for _, account := range res.Result.List {
+		for _, coin := range account.Coin {
+			if string(coin.Coin) == asset {
+				return parseFloat(coin.Equity)
+			}
+		}
+	}
+	return 0, nil
+}
+
+func (e *BybitExecutor) GetPosition(ctx context.Context, symbol string) (*models.Position, error) {
+	return bybitPosition(ctx, e.client, symbol)
+}
+
+// bybitPosition returns the net linear position in symbol; short sizes are negated
+func bybitPosition(ctx context.Context, client *bybit.Client, symbol string) (*models.Position, error) {
+	client, cancel := bybitClient(ctx, client)
+	defer cancel()
+
+	native, err := nativeSymbol(bybitSymbols{}, symbol)
+	if err != nil {
+		return nil, err
+	}
+	symbolStr := bybit.SymbolV5(native)
+	res, err := client.V5().Position().GetPositionInfo(bybit.V5GetPositionInfoParam{
+		Category: bybit.CategoryV5Linear,
+		Symbol:   &symbolStr,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	position := &models.Position{Symbol: symbol}
+	for _, item := range res.Result.List {
+		size, err := parseFloat(item.Size)
+		if err != nil {
+			return nil, err
+		}
+		if size == 0 {
+			continue
+		}
+		if item.Side == bybit.SideSell {
+			size = -size
+		}
+		position.Size += size
+		position.EntryPrice, _ = strconv.ParseFloat(item.AvgPrice, 64)
+	}
+	return position, nil
+}
+
+func (e *BybitExecutor) GetInstrument(ctx context.Context, symbol string) (*models.Instrument, error) {
+	client, cancel := bybitClient(ctx, e.client)
+	defer cancel()
+
+	native, err := nativeSymbol(bybitSymbols{}, symbol)
+	if err != nil {
+		return nil, err
+	}
+	symbolStr := bybit.SymbolV5(native)
+	res, err := client.V5().Market().GetInstrumentsInfo(bybit.V5GetInstrumentsInfoParam{
+		Category: bybit.CategoryV5Linear,
+		Symbol:   &symbolStr,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if res.Result.LinearInverse == nil || len(res.Result.LinearInverse.List) == 0 {
+		return nil, fmt.Errorf("bybit instrument %s not found", symbol)
+	}
+
+	item := res.Result.LinearInverse.List[0]
+	instrument := &models.Instrument{Symbol: symbol}
+	instrument.MinQty, _ = strconv.ParseFloat(item.LotSizeFilter.MinOrderQty, 64)
+	instrument.QtyStep, _ = strconv.ParseFloat(item.LotSizeFilter.QtyStep, 64)
+	instrument.TickSize, _ = strconv.ParseFloat(item.PriceFilter.TickSize, 64)
+	instrument.MinNotional, _ = strconv.ParseFloat(item.LotSizeFilter.MinNotionalValue, 64)
+	return instrument, nil
+}
+
 func (e *BybitExecutor) Close() {
 	// Cleanup
 }
+
+// mapBybitOrderStatus maps Bybit V5 order statuses to the statuses used in the orders table
+func mapBybitOrderStatus(status bybit.OrderStatus) string {
+	switch status {
+	case bybit.OrderStatusCreated, bybit.OrderStatusNew, bybit.OrderStatusUntriggered:
+		return "NEW"
+	case bybit.OrderStatusPartiallyFilled:
+		return "PARTIALLY_FILLED"
+	case bybit.OrderStatusFilled:
+		return "FILLED"
+	case bybit.OrderStatusCancelled, bybit.OrderStatusDeactivated, "PartiallyFilledCanceled":
+		return "CANCELLED"
+	case bybit.OrderStatusRejected:
+		return "REJECTED"
+	default:
+		return string(status)
+	}
+}
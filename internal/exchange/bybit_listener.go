@@ -0,0 +1,163 @@
+package exchange
+
+import (
+	"context"
+	"crypto-sync-bot/internal/config"
+	"crypto-sync-bot/internal/models"
+	"crypto-sync-bot/internal/processor"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hirokisan/bybit/v2"
+)
+
+func init() {
+	RegisterListener(config.ExchangeBybit, func(account config.AccountConfig, cfg *config.Config) (Listener, error) {
+		if account.APIKey == "" {
+			return nil, nil
+		}
+		return NewBybitListener(account), nil
+	})
+}
+
+// BybitListener subscribes to the Bybit V5 private order stream and produces
+// a signal for every filled linear order.
+type BybitListener struct {
+	client   *bybit.Client // REST client for balance queries
+	account  config.AccountConfig
+	mu       sync.Mutex
+	running  bool
+	ctx      context.Context
+	cancel   context.CancelFunc
+	stopChan chan struct{}
+}
+
+func NewBybitListener(account config.AccountConfig) *BybitListener {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &BybitListener{
+		client:   newBybitClient(account),
+		account:  account,
+		ctx:      ctx,
+		cancel:   cancel,
+		stopChan: make(chan struct{}),
+	}
+}
+
+func (b *BybitListener) Name() string {
+	return "Bybit"
+}
+
+func (b *BybitListener) Start() error {
+	b.mu.Lock()
+	if b.running {
+		b.mu.Unlock()
+		return nil
+	}
+	b.running = true
+	b.mu.Unlock()
+
+	go b.connectWebSocket()
+	return nil
+}
+
+func (b *BybitListener) connectWebSocket() {
+	for {
+		select {
+		case <-b.stopChan:
+			return
+		default:
+		}
+
+		if err := b.serve(); err != nil {
+			log.Printf("Bybit WebSocket Error: %v", err)
+		}
+
+		select {
+		case <-b.stopChan:
+			return
+		case <-time.After(2 * time.Second):
+			log.Println("Bybit WebSocket disconnected, reconnecting...")
+		}
+	}
+}
+
+// serve runs one private WebSocket session until it drops or the listener stops
+func (b *BybitListener) serve() error {
+	wsClient := bybit.NewWebsocketClient().WithAuth(b.account.APIKey, b.account.APISecret)
+	if b.account.Testnet {
+		wsClient = wsClient.WithBaseURL(bybit.TestWebsocketBaseURL)
+	}
+
+	svc, err := wsClient.V5().Private()
+	if err != nil {
+		return fmt.Errorf("failed to connect: %w", err)
+	}
+	if err := svc.Subscribe(); err != nil {
+		return fmt.Errorf("failed to authenticate: %w", err)
+	}
+	if _, err := svc.SubscribeOrder(b.handleOrders); err != nil {
+		return fmt.Errorf("failed to subscribe to orders: %w", err)
+	}
+
+	log.Println("Starting Bybit private order stream")
+	return svc.Start(b.ctx, func(isWebsocketClosed bool, err error) {
+		log.Printf("Bybit WebSocket Error (closed=%v): %v", isWebsocketClosed, err)
+	})
+}
+
+func (b *BybitListener) handleOrders(resp bybit.V5WebsocketPrivateOrderResponse) error {
+	for _, order := range resp.Data {
+		if order.Category != string(bybit.CategoryV5Linear) || order.OrderStatus != bybit.OrderStatusFilled {
+			continue
+		}
+
+		symbol, err := bybitSymbols{}.FromExchange(string(order.Symbol))
+		if err != nil {
+			log.Printf("Ignoring Bybit fill with unknown symbol: %v", err)
+			continue
+		}
+		qty, err := parseFloat(order.CumExecQty)
+		if err != nil {
+			log.Printf("Error parsing Quantity from Bybit: %v", err)
+			continue
+		}
+		price, err := parseFloat(order.AvgPrice)
+		if err != nil {
+			log.Printf("Error parsing Price from Bybit: %v", err)
+			continue
+		}
+
+		signal := &models.TradingSignal{
+			SignalID:  order.OrderID,
+			Symbol:    symbol.String(),
+			Side:      strings.ToUpper(string(order.Side)),
+			OrderType: strings.ToUpper(string(order.OrderType)),
+			Quantity:  qty,
+			Price:     price,
+			Timestamp: resp.CreationTime,
+			Source:    b.account.ID,
+		}
+		if err := processor.ProduceSignal(context.Background(), signal); err != nil {
+			log.Printf("Error producing signal from Bybit: %v", err)
+		}
+	}
+	return nil
+}
+
+// GetBalance returns the unified account equity in asset
+func (b *BybitListener) GetBalance(ctx context.Context, asset string) (float64, error) {
+	return bybitEquity(ctx, b.client, asset)
+}
+
+// GetPosition returns the net linear position in symbol
+func (b *BybitListener) GetPosition(ctx context.Context, symbol string) (*models.Position, error) {
+	return bybitPosition(ctx, b.client, symbol)
+}
+
+func (b *BybitListener) Stop() {
+	close(b.stopChan)
+	b.cancel()
+}
@@ -0,0 +1,24 @@
+package exchange
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"hash/fnv"
+)
+
+// clientOrderNumber maps a client order ID onto the numeric client IDs some
+// exchanges use instead of strings, keeping the low bits of its hash. The
+// mapping is stable, so a retried order carries the same number.
+func clientOrderNumber(clientOrderID string, bits uint) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(clientOrderID))
+	return h.Sum64() & (1<<bits - 1)
+}
+
+// randomClientOrderID returns a unique client order ID for orders placed
+// without one, on exchanges that need a client ID to tell orders apart
+func randomClientOrderID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
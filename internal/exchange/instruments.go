@@ -0,0 +1,122 @@
+package exchange
+
+import (
+	"context"
+	"crypto-sync-bot/internal/models"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// InstrumentProvider is implemented by executors that can fetch symbol trading rules
+type InstrumentProvider interface {
+	GetInstrument(ctx context.Context, symbol string) (*models.Instrument, error)
+}
+
+type cachedInstrument struct {
+	instrument *models.Instrument
+	fetchedAt  time.Time
+}
+
+// InstrumentService caches instrument metadata per exchange and symbol and
+// rounds orders to values the exchange will accept.
+type InstrumentService struct {
+	ttl   time.Duration
+	mu    sync.RWMutex
+	cache map[string]cachedInstrument
+}
+
+func NewInstrumentService(ttl time.Duration) *InstrumentService {
+	return &InstrumentService{
+		ttl:   ttl,
+		cache: make(map[string]cachedInstrument),
+	}
+}
+
+// Get returns the cached instrument, fetching it from the provider when missing or expired
+func (s *InstrumentService) Get(ctx context.Context, exchange string, provider InstrumentProvider, symbol string) (*models.Instrument, error) {
+	key := exchange + ":" + symbol
+
+	s.mu.RLock()
+	cached, ok := s.cache[key]
+	s.mu.RUnlock()
+	if ok && time.Since(cached.fetchedAt) < s.ttl {
+		return cached.instrument, nil
+	}
+
+	instrument, err := provider.GetInstrument(ctx, symbol)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s instrument %s: %w", exchange, symbol, err)
+	}
+
+	s.mu.Lock()
+	s.cache[key] = cachedInstrument{instrument: instrument, fetchedAt: time.Now()}
+	s.mu.Unlock()
+
+	return instrument, nil
+}
+
+// Normalize returns a copy of the signal with quantity rounded down to the lot
+// step and price rounded to the tick size. A non-empty reason means the order
+// falls under the exchange minimums and must not be placed.
+func (s *InstrumentService) Normalize(ctx context.Context, exchange string, provider InstrumentProvider, signal *models.TradingSignal) (*models.TradingSignal, string, error) {
+	instrument, err := s.Get(ctx, exchange, provider, signal.Symbol)
+	if err != nil {
+		return nil, "", err
+	}
+
+	rounded := *signal
+	rounded.Quantity = roundToStep(signal.Quantity, instrument.QtyStep, math.Floor)
+	if rounded.Price > 0 {
+		rounded.Price = roundToStep(signal.Price, instrument.TickSize, math.Round)
+	}
+	if rounded.StopLossPrice > 0 {
+		rounded.StopLossPrice = roundToStep(signal.StopLossPrice, instrument.TickSize, math.Round)
+	}
+	if rounded.TakeProfitPrice > 0 {
+		rounded.TakeProfitPrice = roundToStep(signal.TakeProfitPrice, instrument.TickSize, math.Round)
+	}
+
+	if rounded.Quantity <= 0 || rounded.Quantity < instrument.MinQty {
+		return &rounded, fmt.Sprintf("quantity %s (rounded from %s) is below %s minimum %s for %s",
+			formatDecimal(rounded.Quantity), formatDecimal(signal.Quantity), exchange,
+			formatDecimal(instrument.MinQty), signal.Symbol), nil
+	}
+	if instrument.MinNotional > 0 && rounded.Price > 0 && rounded.Quantity*rounded.Price < instrument.MinNotional {
+		return &rounded, fmt.Sprintf("notional %s is below %s minimum %s for %s",
+			formatDecimal(rounded.Quantity*rounded.Price), exchange,
+			formatDecimal(instrument.MinNotional), signal.Symbol), nil
+	}
+
+	return &rounded, "", nil
+}
+
+// roundToStep rounds v to a multiple of step using the given rounding function
+// and trims float noise to the step's precision.
+func roundToStep(v, step float64, round func(float64) float64) float64 {
+	if step <= 0 {
+		return v
+	}
+	decimals := stepDecimals(step)
+	// The epsilon keeps values like 0.3/0.1 = 2.9999999 from flooring to 2
+	units := round(v/step + 1e-9)
+	scale := math.Pow(10, float64(decimals))
+	return math.Round(units*step*scale) / scale
+}
+
+// stepDecimals returns the number of decimal places in a step such as 0.001
+func stepDecimals(step float64) int {
+	s := strconv.FormatFloat(step, 'f', -1, 64)
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		return len(s) - i - 1
+	}
+	return 0
+}
+
+// formatDecimal formats a number without exponent or trailing zeros
+func formatDecimal(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
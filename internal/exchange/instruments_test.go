@@ -0,0 +1,105 @@
+package exchange
+
+import (
+	"context"
+	"crypto-sync-bot/internal/models"
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRoundToStep(t *testing.T) {
+	tests := []struct {
+		name  string
+		v     float64
+		step  float64
+		round func(float64) float64
+		want  float64
+	}{
+		{"floor to lot step", 1.23456, 0.001, math.Floor, 1.234},
+		{"exact multiple survives float noise", 0.3, 0.1, math.Floor, 0.3},
+		{"exact multiple of small step", 0.0003, 0.0001, math.Floor, 0.0003},
+		{"round to tick", 100.26, 0.5, math.Round, 100.5},
+		{"round half tick up", 100.25, 0.5, math.Round, 100.5},
+		{"round down to tick", 100.24, 0.5, math.Round, 100},
+		{"integer step", 17, 5, math.Floor, 15},
+		{"below one step", 0.0009, 0.001, math.Floor, 0},
+		{"no step leaves value", 1.23456, 0, math.Floor, 1.23456},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := roundToStep(tt.v, tt.step, tt.round); got != tt.want {
+				t.Errorf("roundToStep(%v, %v) = %v, want %v", tt.v, tt.step, got, tt.want)
+			}
+		})
+	}
+}
+
+// staticInstrument provides one instrument for every symbol
+type staticInstrument models.Instrument
+
+func (s staticInstrument) GetInstrument(ctx context.Context, symbol string) (*models.Instrument, error) {
+	instrument := models.Instrument(s)
+	instrument.Symbol = symbol
+	return &instrument, nil
+}
+
+func TestNormalize(t *testing.T) {
+	instrument := staticInstrument{MinQty: 0.001, QtyStep: 0.001, TickSize: 0.1, MinNotional: 5}
+
+	tests := []struct {
+		name   string
+		signal models.TradingSignal
+
+		want       models.TradingSignal
+		wantReason string // substring of the skip reason, empty when placeable
+	}{
+		{
+			name:   "rounds quantity down and prices to tick",
+			signal: models.TradingSignal{Symbol: "BTC-USDT", Quantity: 0.12345, Price: 100.06, StopLossPrice: 95.04, TakeProfitPrice: 110.08},
+			want:   models.TradingSignal{Symbol: "BTC-USDT", Quantity: 0.123, Price: 100.1, StopLossPrice: 95, TakeProfitPrice: 110.1},
+		},
+		{
+			name:   "market order keeps zero price",
+			signal: models.TradingSignal{Symbol: "BTC-USDT", Quantity: 0.0109},
+			want:   models.TradingSignal{Symbol: "BTC-USDT", Quantity: 0.01},
+		},
+		{
+			name:       "below minimum quantity",
+			signal:     models.TradingSignal{Symbol: "BTC-USDT", Quantity: 0.0009, Price: 100},
+			want:       models.TradingSignal{Symbol: "BTC-USDT", Quantity: 0, Price: 100},
+			wantReason: "quantity 0 (rounded from 0.0009) is below Test minimum 0.001",
+		},
+		{
+			name:       "below minimum notional",
+			signal:     models.TradingSignal{Symbol: "BTC-USDT", Quantity: 0.04, Price: 100},
+			want:       models.TradingSignal{Symbol: "BTC-USDT", Quantity: 0.04, Price: 100},
+			wantReason: "notional 4 is below Test minimum 5",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewInstrumentService(time.Minute)
+			signal := tt.signal
+			got, reason, err := s.Normalize(context.Background(), "Test", instrument, &signal)
+			if err != nil {
+				t.Fatalf("Normalize: %v", err)
+			}
+			if *got != tt.want {
+				t.Errorf("Normalize = %+v, want %+v", *got, tt.want)
+			}
+			if signal != tt.signal {
+				t.Errorf("Normalize changed its input to %+v", signal)
+			}
+			if tt.wantReason == "" && reason != "" {
+				t.Errorf("unexpected skip reason %q", reason)
+			}
+			if tt.wantReason != "" && !strings.Contains(reason, tt.wantReason) {
+				t.Errorf("skip reason %q, want it to contain %q", reason, tt.wantReason)
+			}
+		})
+	}
+}
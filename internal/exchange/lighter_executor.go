@@ -2,6 +2,7 @@ package exchange
 
 import (
 	"bytes"
+	"context"
 	"crypto/hmac"
 	"crypto/sha256"
 	"encoding/hex"
@@ -9,25 +10,106 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"math"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"crypto-sync-bot/internal/config"
 	"crypto-sync-bot/internal/models"
 )
 
-const lighterBaseURL = "https://mainnet.zklighter.elliot.ai"
+const (
+	lighterBaseURL       = "https://mainnet.zklighter.elliot.ai"
+	lighterMarketRefresh = 10 * time.Minute
+	// lighterClientIndexBits keeps derived client order indexes within the 48 bits Lighter accepts
+	lighterClientIndexBits = 48
+	lighterOrdersPageSize  = 100 // the most accountInactiveOrders returns at once
+)
+
+// Lighter order types of the reduce-only trigger orders that protect a position
+const (
+	lighterOrderStopLoss   = 2
+	lighterOrderTakeProfit = 4
+)
+
+// lighterProtectionLegs are the trigger orders that protect the position an
+// order opens. Each leg's client index is derived from the order's, so the
+// legs of an order can be found from the order alone.
+var lighterProtectionLegs = []struct {
+	name      string
+	orderType int
+}{
+	{"stop-loss", lighterOrderStopLoss},
+	{"take-profit", lighterOrderTakeProfit},
+}
+
+// Lighter transaction status codes returned by /api/v1/tx
+const (
+	lighterTxPending   = 0
+	lighterTxQueued    = 1
+	lighterTxCommitted = 2
+	lighterTxExecuted  = 3
+	lighterTxFailed    = 4
+)
+
+func init() {
+	RegisterExecutor("lighter", func(account config.AccountConfig) (models.ExchangeExecutor, error) {
+		if account.APIKey == "" {
+			return nil, nil
+		}
+		return NewLighterExecutor(account), nil
+	})
+}
+
+// lighterMarket is one entry of the /api/v1/orderBooks listing
+type lighterMarket struct {
+	Symbol                 string `json:"symbol"`
+	MarketID               int    `json:"market_id"`
+	Status                 string `json:"status"`
+	MinBaseAmount          string `json:"min_base_amount"`
+	MinQuoteAmount         string `json:"min_quote_amount"`
+	SupportedSizeDecimals  int    `json:"supported_size_decimals"`
+	SupportedPriceDecimals int    `json:"supported_price_decimals"`
+}
+
+// lighterOrder is one entry of the account active/inactive order listings
+type lighterOrder struct {
+	OrderIndex        int64  `json:"order_index"`
+	ClientOrderIndex  int64  `json:"client_order_index"`
+	Status            string `json:"status"`
+	Type              string `json:"type"` // "limit", "market", "stop-loss", "take-profit"...
+	ReduceOnly        bool   `json:"reduce_only"`
+	InitialBaseAmount string `json:"initial_base_amount"`
+	FilledBaseAmount  string `json:"filled_base_amount"`
+	TriggerPrice      string `json:"trigger_price"`
+}
+
+// isTrigger reports whether the order waits for a trigger price, like the
+// stop-loss and take-profit legs placed by syncProtection
+func (o *lighterOrder) isTrigger() bool {
+	return strings.HasPrefix(o.Type, "stop-loss") || strings.HasPrefix(o.Type, "take-profit")
+}
 
 type LighterExecutor struct {
-	config     *config.Config
+	account    config.AccountConfig
 	httpClient *http.Client
+	symbols    lighterSymbols
+
+	marketsMu        sync.RWMutex
+	markets          map[string]lighterMarket // keyed by base asset, e.g. "BTC"
+	marketsFetchedAt time.Time
 }
 
-func NewLighterExecutor(cfg *config.Config) *LighterExecutor {
+func NewLighterExecutor(account config.AccountConfig) *LighterExecutor {
 	return &LighterExecutor{
-		config:     cfg,
-		httpClient: &http.Client{Timeout: 30 * time.Second},
+		account:    account,
+		httpClient: &http.Client{},
+		symbols:    lighterSymbols{stablecoinsAsUSDC: account.SettleInUSDC},
+		markets:    make(map[string]lighterMarket),
 	}
 }
 
@@ -35,9 +117,7 @@ func (e *LighterExecutor) Name() string {
 	return "Lighter"
 }
 
-func (e *LighterExecutor) PlaceOrder(signal *models.TradingSignal) (*models.OrderResult, error) {
-	lighterCfg := e.config.GetLighter()
-	
+func (e *LighterExecutor) PlaceOrder(ctx context.Context, signal *models.TradingSignal) (*models.OrderResult, error) {
 	// Map side: Lighter uses IsAsk=0 for Buy, IsAsk=1 for Sell
 	isAsk := 0
 	if signal.Side == "SELL" {
@@ -50,23 +130,56 @@ func (e *LighterExecutor) PlaceOrder(signal *models.TradingSignal) (*models.Orde
 		orderType = 0
 	}
 	
+	// Lighter identifies markets by market_id
+	market, err := e.market(ctx, signal.Symbol)
+	if err != nil {
+		return &models.OrderResult{
+			Exchange:     "Lighter",
+			Symbol:       signal.Symbol,
+			Status:       "failed",
+			ErrorMessage: err.Error(),
+			Timestamp:    signal.Timestamp,
+		}, err
+	}
+
+	// Retried orders reuse the client index derived from the client order ID
+	if signal.ClientOrderID == "" {
+		placed := *signal
+		placed.ClientOrderID = randomClientOrderID()
+		signal = &placed
+	}
+	clientOrderIndex := lighterClientIndex(signal.ClientOrderID)
+
+	// Reduce-only legs outlive the position they protected, so they are cleared
+	// once it is closed: before an order on a flat market, and right after a
+	// market order that closes the position
+	closing := false
+	if position, err := e.GetPosition(ctx, signal.Symbol); err != nil {
+		log.Printf("Lighter could not check the %s position for stale protection: %v", signal.Symbol, err)
+	} else if position.Size == 0 {
+		if err := e.cancelOrphanedProtection(ctx, market.MarketID); err != nil {
+			log.Printf("Lighter could not clear stale protection in %s: %v", signal.Symbol, err)
+		}
+	} else {
+		closing = orderType == 1 && closesPosition(isAsk, signal.Quantity, position.Size)
+	}
+
 	// Build order request
-	// Note: Lighter uses market_id, we'll need to map symbols
-	// For simplicity, assume symbol is already a market_id or use a mapping
 	orderReq := map[string]interface{}{
 		"tx_type": "CreateOrder",
 		"tx_info": map[string]interface{}{
-			"market_id":     getMarketID(signal.Symbol),
-			"amount":        fmt.Sprintf("%f", signal.Quantity),
-			"price":         fmt.Sprintf("%f", signal.Price),
-			"is_ask":        isAsk,
-			"type":          orderType,
-			"account_index": lighterCfg.AccountIndex,
-			"nonce":         time.Now().UnixNano(),
+			"market_id":          market.MarketID,
+			"client_order_index": clientOrderIndex,
+			"amount":             formatDecimal(signal.Quantity),
+			"price":              formatDecimal(signal.Price),
+			"is_ask":             isAsk,
+			"type":               orderType,
+			"account_index":      e.account.AccountIndex,
+			"nonce":              time.Now().UnixNano(),
 		},
 	}
 	
-	respBody, err := e.signedRequest("POST", "/api/v1/sendTx", orderReq)
+	respBody, err := e.signedRequest(ctx, "POST", "/api/v1/sendTx", orderReq)
 	if err != nil {
 		log.Printf("Lighter Order Failed: %v", err)
 		return &models.OrderResult{
@@ -98,53 +211,613 @@ func (e *LighterExecutor) PlaceOrder(signal *models.TradingSignal) (*models.Orde
 		}, fmt.Errorf("lighter error: %s", resp.Error)
 	}
 	
-	return &models.OrderResult{
+	result := &models.OrderResult{
 		Exchange:  "Lighter",
 		Symbol:    signal.Symbol,
 		Status:    "success",
 		OrderID:   resp.Result.TxHash,
 		Timestamp: signal.Timestamp,
-	}, nil
+	}
+	if closing {
+		if err := e.cancelOrphanedProtection(ctx, market.MarketID); err != nil {
+			log.Printf("Lighter could not clear the protection of the closed %s position: %v", signal.Symbol, err)
+		}
+	}
+	// The order stands either way; a missing stop is reported, not retried
+	if signal.StopLossPrice > 0 || signal.TakeProfitPrice > 0 {
+		if err := e.syncProtection(ctx, market.MarketID, clientOrderIndex, signal); err != nil {
+			log.Printf("Lighter order %s placed without full protection: %v", result.OrderID, err)
+			result.ErrorMessage = err.Error()
+		}
+	}
+	return result, nil
+}
+
+// closesPosition reports whether an order on side isAsk of quantity closes a position of size
+func closesPosition(isAsk int, quantity, size float64) bool {
+	if isAsk == 1 {
+		return size > 0 && quantity >= size
+	}
+	return size < 0 && quantity >= -size
+}
+
+// SupportsConditionalOrders reports that stop-loss and take-profit are placed with the order
+func (e *LighterExecutor) SupportsConditionalOrders() bool {
+	return true
+}
+
+// syncProtection brings the legs protecting the order with client index
+// parentIndex in line with signal. The legs are reduce-only trigger orders on
+// the closing side, as Lighter cannot attach them to an order, and each
+// triggers a market order for the full quantity. Missing legs are placed,
+// existing ones moved to the signal's quantity and trigger prices, and legs
+// the signal no longer carries a price for are cancelled.
+func (e *LighterExecutor) syncProtection(ctx context.Context, marketID int, parentIndex int64, signal *models.TradingSignal) error {
+	active, err := e.activeOrders(ctx, marketID)
+	if err != nil {
+		return err
+	}
+	triggers := map[int]float64{
+		lighterOrderStopLoss:   signal.StopLossPrice,
+		lighterOrderTakeProfit: signal.TakeProfitPrice,
+	}
+	isAsk := 1 // legs close the position the order opens
+	if signal.Side == "SELL" {
+		isAsk = 0
+	}
+
+	var failed []string
+	for _, leg := range lighterProtectionLegs {
+		trigger := triggers[leg.orderType]
+		index := lighterLegIndex(parentIndex, leg.name)
+		var err error
+		if existing := matchClientIndex(active, index); existing != nil {
+			err = e.updateLeg(ctx, marketID, existing, signal.Quantity, trigger)
+		} else if trigger > 0 {
+			// Like the order itself, retries reuse the client index so a leg is not placed twice
+			_, err = e.sendTx(ctx, "CreateOrder", map[string]interface{}{
+				"market_id":          marketID,
+				"client_order_index": index,
+				"amount":             formatDecimal(signal.Quantity),
+				"price":              formatDecimal(trigger),
+				"trigger_price":      formatDecimal(trigger),
+				"is_ask":             isAsk,
+				"type":               leg.orderType,
+				"reduce_only":        1,
+				"account_index":      e.account.AccountIndex,
+				"nonce":              time.Now().UnixNano(),
+			})
+		}
+		if err != nil {
+			failed = append(failed, fmt.Sprintf("%s: %v", leg.name, err))
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to update %s", strings.Join(failed, "; "))
+	}
+	return nil
+}
+
+// shrinkProtection fits the legs of a cancelled order to the quantity it
+// filled before, which is all they still protect; with nothing filled they
+// are cancelled
+func (e *LighterExecutor) shrinkProtection(ctx context.Context, marketID int, parent *lighterOrder) error {
+	active, err := e.activeOrders(ctx, marketID)
+	if err != nil {
+		return err
+	}
+	filled, _ := strconv.ParseFloat(parent.FilledBaseAmount, 64)
+
+	var failed []string
+	for _, leg := range lighterProtectionLegs {
+		existing := matchClientIndex(active, lighterLegIndex(parent.ClientOrderIndex, leg.name))
+		if existing == nil {
+			continue
+		}
+		trigger, _ := strconv.ParseFloat(existing.TriggerPrice, 64)
+		if err := e.updateLeg(ctx, marketID, existing, filled, trigger); err != nil {
+			failed = append(failed, fmt.Sprintf("%s: %v", leg.name, err))
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to update %s", strings.Join(failed, "; "))
+	}
+	return nil
+}
+
+// cancelOrphanedProtection cancels the reduce-only trigger orders of a market
+// whose position is closed, except the legs of orders still resting, which
+// protect the position those will open
+func (e *LighterExecutor) cancelOrphanedProtection(ctx context.Context, marketID int) error {
+	active, err := e.activeOrders(ctx, marketID)
+	if err != nil {
+		return err
+	}
+	resting := make(map[int64]bool)
+	for _, order := range active {
+		if order.isTrigger() {
+			continue
+		}
+		for _, leg := range lighterProtectionLegs {
+			resting[lighterLegIndex(order.ClientOrderIndex, leg.name)] = true
+		}
+	}
+
+	var failed []string
+	for _, order := range active {
+		if !order.isTrigger() || !order.ReduceOnly || resting[order.ClientOrderIndex] {
+			continue
+		}
+		if err := e.cancelIndex(ctx, marketID, order.OrderIndex); err != nil {
+			failed = append(failed, fmt.Sprintf("%d: %v", order.OrderIndex, err))
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to cancel trigger orders %s", strings.Join(failed, "; "))
+	}
+	return nil
 }
 
-func (e *LighterExecutor) GetOrder(orderID, symbol string) (*models.OrderResult, error) {
-	// Lighter uses tx_hash for order tracking
-	// This would query the transaction status
-	return &models.OrderResult{
+// updateLeg moves a protection leg to quantity and trigger, or cancels it if either is not set
+func (e *LighterExecutor) updateLeg(ctx context.Context, marketID int, leg *lighterOrder, quantity, trigger float64) error {
+	if quantity <= 0 || trigger <= 0 {
+		return e.cancelIndex(ctx, marketID, leg.OrderIndex)
+	}
+	_, err := e.sendTx(ctx, "ModifyOrder", map[string]interface{}{
+		"market_id":     marketID,
+		"index":         leg.OrderIndex,
+		"base_amount":   formatDecimal(quantity),
+		"price":         formatDecimal(trigger),
+		"trigger_price": formatDecimal(trigger),
+		"account_index": e.account.AccountIndex,
+		"nonce":         time.Now().UnixNano(),
+	})
+	return err
+}
+
+// lighterLegIndex derives the client index of a protection leg from the client
+// index of the order it protects
+func lighterLegIndex(parentIndex int64, leg string) int64 {
+	return lighterClientIndex(strconv.FormatInt(parentIndex, 10) + ":" + leg)
+}
+
+// lighterTx is the state of a submitted transaction, from /api/v1/tx
+type lighterTx struct {
+	Status int    `json:"status"`
+	Info   string `json:"info"` // JSON-encoded tx_info as submitted
+}
+
+// GetOrder resolves the order orderID refers to and maps its state to the
+// normalized order statuses. Lighter order IDs are either the hash of the
+// transaction that created the order, as PlaceOrder returns, or the order
+// index, as GetOrderByClientID returns.
+func (e *LighterExecutor) GetOrder(ctx context.Context, orderID, symbol string) (*models.OrderResult, error) {
+	result := &models.OrderResult{
 		Exchange: "Lighter",
 		Symbol:   symbol,
 		OrderID:  orderID,
-		Status:   "unknown",
-	}, nil
+	}
+
+	if _, ok := lighterOrderIndex(orderID); ok {
+		_, order, err := e.resolveOrder(ctx, orderID, symbol)
+		if err != nil {
+			return nil, err
+		}
+		result.Status = mapLighterOrderStatus(order)
+		result.FilledQuantity, _ = strconv.ParseFloat(order.FilledBaseAmount, 64)
+		return result, nil
+	}
+
+	tx, err := e.getTx(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	switch tx.Status {
+	case lighterTxPending, lighterTxQueued, lighterTxCommitted:
+		result.Status = "NEW"
+		return result, nil
+	case lighterTxFailed:
+		result.Status = "REJECTED"
+		return result, nil
+	case lighterTxExecuted:
+	default:
+		return nil, fmt.Errorf("lighter tx %s has unknown status %d", orderID, tx.Status)
+	}
+
+	_, order, err := e.txOrder(ctx, orderID, tx)
+	if err != nil {
+		return nil, err
+	}
+	result.Status = mapLighterOrderStatus(order)
+	result.FilledQuantity, _ = strconv.ParseFloat(order.FilledBaseAmount, 64)
+	return result, nil
 }
 
-func (e *LighterExecutor) Close() {
-	// Cleanup if needed
+func (e *LighterExecutor) getTx(ctx context.Context, hash string) (*lighterTx, error) {
+	respBody, err := e.signedRequest(ctx, "GET", "/api/v1/tx?by=hash&value="+url.QueryEscape(hash), nil)
+	if err != nil {
+		return nil, err
+	}
+	var tx lighterTx
+	if err := json.Unmarshal(respBody, &tx); err != nil {
+		return nil, fmt.Errorf("failed to parse tx: %w", err)
+	}
+	return &tx, nil
 }
 
-func (e *LighterExecutor) signedRequest(method, path string, body interface{}) ([]byte, error) {
-	lighterCfg := e.config.GetLighter()
-	
-	jsonBody, err := json.Marshal(body)
+// resolveOrder finds the order orderID refers to, with its market: an order
+// index is looked up in the symbol's market, anything else is taken as the
+// hash of the transaction that created the order
+func (e *LighterExecutor) resolveOrder(ctx context.Context, orderID, symbol string) (int, *lighterOrder, error) {
+	if index, ok := lighterOrderIndex(orderID); ok {
+		market, err := e.market(ctx, symbol)
+		if err != nil {
+			return 0, nil, err
+		}
+		order, err := e.findOrderIndex(ctx, market.MarketID, index)
+		return market.MarketID, order, err
+	}
+	tx, err := e.getTx(ctx, orderID)
+	if err != nil {
+		return 0, nil, err
+	}
+	return e.txOrder(ctx, orderID, tx)
+}
+
+// lighterOrderIndex parses an order ID that is an order index. Tx hashes are
+// longer hex strings and never parse.
+func lighterOrderIndex(orderID string) (int64, bool) {
+	index, err := strconv.ParseInt(orderID, 10, 64)
+	return index, err == nil
+}
+
+// txOrder finds the order created by the executed transaction hash, with its market
+func (e *LighterExecutor) txOrder(ctx context.Context, hash string, tx *lighterTx) (int, *lighterOrder, error) {
+	if tx.Status != lighterTxExecuted {
+		return 0, nil, fmt.Errorf("lighter tx %s is not executed yet (status %d)", hash, tx.Status)
+	}
+	var info struct {
+		MarketID         int   `json:"market_id"`
+		ClientOrderIndex int64 `json:"client_order_index"`
+	}
+	if err := json.Unmarshal([]byte(tx.Info), &info); err != nil {
+		return 0, nil, fmt.Errorf("failed to parse tx info: %w", err)
+	}
+	order, err := e.findOrder(ctx, info.MarketID, info.ClientOrderIndex)
+	return info.MarketID, order, err
+}
+
+// CancelOrder cancels the order orderID refers to, a tx hash or order index
+func (e *LighterExecutor) CancelOrder(ctx context.Context, orderID, symbol string) error {
+	marketID, order, err := e.resolveOrder(ctx, orderID, symbol)
+	if err != nil {
+		return err
+	}
+	if err := e.cancelIndex(ctx, marketID, order.OrderIndex); err != nil {
+		return err
+	}
+	// The order is gone either way; legs left behind are cleared with the position
+	if err := e.shrinkProtection(ctx, marketID, order); err != nil {
+		log.Printf("Lighter order %s cancelled, but not its protection: %v", orderID, err)
+	}
+	return nil
+}
+
+// cancelIndex cancels one order of marketID by its order index
+func (e *LighterExecutor) cancelIndex(ctx context.Context, marketID int, orderIndex int64) error {
+	_, err := e.sendTx(ctx, "CancelOrder", map[string]interface{}{
+		"market_id":     marketID,
+		"index":         orderIndex,
+		"account_index": e.account.AccountIndex,
+		"nonce":         time.Now().UnixNano(),
+	})
+	return err
+}
+
+// AmendOrder modifies the order orderID refers to in place, so it keeps being
+// identified by the same ID. Its protection legs follow the signal's quantity
+// and stop-loss and take-profit prices.
+func (e *LighterExecutor) AmendOrder(ctx context.Context, orderID string, signal *models.TradingSignal) (*models.OrderResult, error) {
+	marketID, order, err := e.resolveOrder(ctx, orderID, signal.Symbol)
 	if err != nil {
 		return nil, err
 	}
+	_, err = e.sendTx(ctx, "ModifyOrder", map[string]interface{}{
+		"market_id":     marketID,
+		"index":         order.OrderIndex,
+		"base_amount":   formatDecimal(signal.Quantity),
+		"price":         formatDecimal(signal.Price),
+		"account_index": e.account.AccountIndex,
+		"nonce":         time.Now().UnixNano(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	result := &models.OrderResult{
+		Exchange:  "Lighter",
+		Symbol:    signal.Symbol,
+		Status:    "success",
+		OrderID:   orderID,
+		Timestamp: signal.Timestamp,
+	}
+	if err := e.syncProtection(ctx, marketID, order.ClientOrderIndex, signal); err != nil {
+		log.Printf("Lighter order %s amended without full protection: %v", orderID, err)
+		result.ErrorMessage = err.Error()
+	}
+	return result, nil
+}
+
+// sendTx submits a transaction and returns its hash
+func (e *LighterExecutor) sendTx(ctx context.Context, txType string, info map[string]interface{}) (string, error) {
+	respBody, err := e.signedRequest(ctx, "POST", "/api/v1/sendTx", map[string]interface{}{
+		"tx_type": txType,
+		"tx_info": info,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	var resp struct {
+		Result struct {
+			TxHash string `json:"tx_hash"`
+		} `json:"result"`
+		Error string `json:"error"`
+	}
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return "", fmt.Errorf("failed to parse response: %w", err)
+	}
+	if resp.Error != "" {
+		return "", fmt.Errorf("lighter error: %s", resp.Error)
+	}
+	return resp.Result.TxHash, nil
+}
+
+// GetOrderByClientID looks up an order by the client index derived from clientOrderID
+func (e *LighterExecutor) GetOrderByClientID(ctx context.Context, clientOrderID, symbol string) (*models.OrderResult, error) {
+	market, err := e.market(ctx, symbol)
+	if err != nil {
+		return nil, err
+	}
+	order, err := e.findOrder(ctx, market.MarketID, lighterClientIndex(clientOrderID))
+	if err != nil {
+		return nil, err
+	}
+	// The creating tx is not known here; the order index identifies the
+	// order to GetOrder, CancelOrder and AmendOrder just as well
+	result := &models.OrderResult{
+		Exchange: "Lighter",
+		Symbol:   symbol,
+		OrderID:  strconv.FormatInt(order.OrderIndex, 10),
+		Status:   mapLighterOrderStatus(order),
+	}
+	result.FilledQuantity, _ = strconv.ParseFloat(order.FilledBaseAmount, 64)
+	return result, nil
+}
+
+// lighterClientIndex derives the numeric client_order_index from a client order ID
+func lighterClientIndex(clientOrderID string) int64 {
+	return int64(clientOrderNumber(clientOrderID, lighterClientIndexBits))
+}
+
+// findOrder looks up an order by client_order_index, first among active orders
+// and then through the pages of order history
+func (e *LighterExecutor) findOrder(ctx context.Context, marketID int, clientOrderIndex int64) (*lighterOrder, error) {
+	order, err := e.searchOrders(ctx, marketID, func(o *lighterOrder) bool { return o.ClientOrderIndex == clientOrderIndex })
+	if err != nil {
+		return nil, err
+	}
+	if order == nil {
+		return nil, fmt.Errorf("lighter order with client index %d in market %d: %w", clientOrderIndex, marketID, models.ErrOrderNotFound)
+	}
+	return order, nil
+}
+
+// findOrderIndex looks up an order by its order index, like findOrder
+func (e *LighterExecutor) findOrderIndex(ctx context.Context, marketID int, orderIndex int64) (*lighterOrder, error) {
+	order, err := e.searchOrders(ctx, marketID, func(o *lighterOrder) bool { return o.OrderIndex == orderIndex })
+	if err != nil {
+		return nil, err
+	}
+	if order == nil {
+		return nil, fmt.Errorf("lighter order %d in market %d: %w", orderIndex, marketID, models.ErrOrderNotFound)
+	}
+	return order, nil
+}
+
+// searchOrders returns the first order of marketID that match accepts, looking
+// among active orders and then through the pages of order history, or nil
+func (e *LighterExecutor) searchOrders(ctx context.Context, marketID int, match func(*lighterOrder) bool) (*lighterOrder, error) {
+	orders, err := e.activeOrders(ctx, marketID)
+	if err != nil {
+		return nil, err
+	}
+	if order := matchOrder(orders, match); order != nil {
+		return order, nil
+	}
+
+	inactive := fmt.Sprintf("/api/v1/accountInactiveOrders?account_index=%d&market_id=%d&limit=%d", e.account.AccountIndex, marketID, lighterOrdersPageSize)
+	cursor := ""
+	for {
+		path := inactive
+		if cursor != "" {
+			path += "&cursor=" + url.QueryEscape(cursor)
+		}
+		orders, next, err := e.listOrders(ctx, path)
+		if err != nil {
+			return nil, err
+		}
+		if order := matchOrder(orders, match); order != nil {
+			return order, nil
+		}
+		if next == "" || next == cursor || len(orders) == 0 {
+			return nil, nil
+		}
+		cursor = next
+	}
+}
+
+// activeOrders lists the orders of marketID resting in the book or waiting for a trigger
+func (e *LighterExecutor) activeOrders(ctx context.Context, marketID int) ([]lighterOrder, error) {
+	orders, _, err := e.listOrders(ctx, fmt.Sprintf("/api/v1/accountActiveOrders?account_index=%d&market_id=%d", e.account.AccountIndex, marketID))
+	return orders, err
+}
+
+// listOrders fetches one page of orders and the cursor of the next page, if any
+func (e *LighterExecutor) listOrders(ctx context.Context, path string) ([]lighterOrder, string, error) {
+	respBody, err := e.signedRequest(ctx, "GET", path, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	var resp struct {
+		Orders     []lighterOrder `json:"orders"`
+		NextCursor string         `json:"next_cursor"`
+	}
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return nil, "", fmt.Errorf("failed to parse orders: %w", err)
+	}
+	return resp.Orders, resp.NextCursor, nil
+}
+
+func matchOrder(orders []lighterOrder, match func(*lighterOrder) bool) *lighterOrder {
+	for i := range orders {
+		if match(&orders[i]) {
+			return &orders[i]
+		}
+	}
+	return nil
+}
+
+func matchClientIndex(orders []lighterOrder, clientOrderIndex int64) *lighterOrder {
+	return matchOrder(orders, func(o *lighterOrder) bool { return o.ClientOrderIndex == clientOrderIndex })
+}
+
+// GetBalance returns the total asset value of the configured account.
+// Lighter accounts are USDC-collateralized, so asset is informational only.
+func (e *LighterExecutor) GetBalance(ctx context.Context, asset string) (float64, error) {
+	path := fmt.Sprintf("/api/v1/account?by=index&value=%d", e.account.AccountIndex)
+	respBody, err := e.signedRequest(ctx, "GET", path, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	var resp struct {
+		Accounts []struct {
+			TotalAssetValue string `json:"total_asset_value"`
+		} `json:"accounts"`
+	}
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return 0, err
+	}
+	if len(resp.Accounts) == 0 {
+		return 0, fmt.Errorf("lighter account %d not found", e.account.AccountIndex)
+	}
+	return parseFloat(resp.Accounts[0].TotalAssetValue)
+}
+
+// GetPosition returns the net position in symbol from the account's position list
+func (e *LighterExecutor) GetPosition(ctx context.Context, symbol string) (*models.Position, error) {
+	market, err := e.market(ctx, symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	path := fmt.Sprintf("/api/v1/account?by=index&value=%d", e.account.AccountIndex)
+	respBody, err := e.signedRequest(ctx, "GET", path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp struct {
+		Accounts []struct {
+			Positions []struct {
+				MarketID      int    `json:"market_id"`
+				Sign          int    `json:"sign"` // 1 long, -1 short
+				Position      string `json:"position"`
+				AvgEntryPrice string `json:"avg_entry_price"`
+			} `json:"positions"`
+		} `json:"accounts"`
+	}
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return nil, err
+	}
+	if len(resp.Accounts) == 0 {
+		return nil, fmt.Errorf("lighter account %d not found", e.account.AccountIndex)
+	}
+
+	position := &models.Position{Symbol: symbol}
+	for _, p := range resp.Accounts[0].Positions {
+		if p.MarketID != market.MarketID {
+			continue
+		}
+		size, err := parseFloat(p.Position)
+		if err != nil {
+			return nil, err
+		}
+		if p.Sign < 0 {
+			size = -size
+		}
+		position.Size = size
+		position.EntryPrice, _ = strconv.ParseFloat(p.AvgEntryPrice, 64)
+	}
+	return position, nil
+}
+
+func (e *LighterExecutor) GetInstrument(ctx context.Context, symbol string) (*models.Instrument, error) {
+	market, err := e.market(ctx, symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	instrument := &models.Instrument{
+		Symbol:   symbol,
+		QtyStep:  math.Pow(10, -float64(market.SupportedSizeDecimals)),
+		TickSize: math.Pow(10, -float64(market.SupportedPriceDecimals)),
+	}
+	instrument.MinQty, _ = strconv.ParseFloat(market.MinBaseAmount, 64)
+	instrument.MinNotional, _ = strconv.ParseFloat(market.MinQuoteAmount, 64)
+	return instrument, nil
+}
+
+func (e *LighterExecutor) Close() {
+	// Cleanup if needed
+}
+
+func (e *LighterExecutor) baseURL() string {
+	if u := e.account.BaseURL; u != "" {
+		return strings.TrimRight(u, "/")
+	}
+	return lighterBaseURL
+}
+
+func (e *LighterExecutor) signedRequest(ctx context.Context, method, path string, body interface{}) ([]byte, error) {
+	var jsonBody []byte
+	if body != nil {
+		var err error
+		jsonBody, err = json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+	}
 	
 	timestamp := strconv.FormatInt(time.Now().UnixMilli(), 10)
 	
 	// Create signature: HMAC-SHA256 of timestamp + body
-	mac := hmac.New(sha256.New, []byte(lighterCfg.APISecret))
+	mac := hmac.New(sha256.New, []byte(e.account.APISecret))
 	mac.Write([]byte(timestamp))
 	mac.Write(jsonBody)
 	signature := hex.EncodeToString(mac.Sum(nil))
 	
-	req, err := http.NewRequest(method, lighterBaseURL+path, bytes.NewBuffer(jsonBody))
+	ctx, cancel := requestContext(ctx)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, method, e.baseURL()+path, bytes.NewBuffer(jsonBody))
 	if err != nil {
 		return nil, err
 	}
 	
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("X-Lighter-API-Key", lighterCfg.APIKey)
+	req.Header.Set("X-Lighter-API-Key", e.account.APIKey)
 	req.Header.Set("X-Lighter-Signature", signature)
 	req.Header.Set("X-Lighter-Timestamp", timestamp)
 	
@@ -166,19 +839,87 @@ func (e *LighterExecutor) signedRequest(method, path string, body interface{}) (
 	return respBody, nil
 }
 
-// getMarketID maps symbol to Lighter market_id
-// Common mappings - in production this should query /api/v1/orderBooks
-func getMarketID(symbol string) int {
-	marketMap := map[string]int{
-		"BTCUSDT": 1,
-		"ETHUSDT": 2,
-		"SOLUSDT": 3,
-		"BTC_USDT": 1,
-		"ETH_USDT": 2,
-		"SOL_USDT": 3,
+// market returns the Lighter market for a symbol. Markets are discovered from
+// /api/v1/orderBooks and refreshed every lighterMarketRefresh.
+func (e *LighterExecutor) market(ctx context.Context, symbol string) (lighterMarket, error) {
+	base, err := nativeSymbol(e.symbols, symbol)
+	if err != nil {
+		return lighterMarket{}, err
+	}
+
+	e.marketsMu.RLock()
+	market, ok := e.markets[base]
+	fresh := time.Since(e.marketsFetchedAt) < lighterMarketRefresh
+	e.marketsMu.RUnlock()
+
+	if fresh {
+		if !ok {
+			return lighterMarket{}, fmt.Errorf("lighter: unknown market for %s", symbol)
+		}
+		return market, nil
+	}
+
+	if err := e.refreshMarkets(ctx); err != nil {
+		if ok {
+			log.Printf("Lighter: market refresh failed, using cached market %s: %v", base, err)
+			return market, nil
+		}
+		return lighterMarket{}, err
+	}
+
+	e.marketsMu.RLock()
+	market, ok = e.markets[base]
+	e.marketsMu.RUnlock()
+	if !ok {
+		return lighterMarket{}, fmt.Errorf("lighter: unknown market for %s", symbol)
 	}
-	if id, ok := marketMap[symbol]; ok {
-		return id
+	return market, nil
+}
+
+func (e *LighterExecutor) refreshMarkets(ctx context.Context) error {
+	respBody, err := e.signedRequest(ctx, "GET", "/api/v1/orderBooks", nil)
+	if err != nil {
+		return err
+	}
+
+	var resp struct {
+		OrderBooks []lighterMarket `json:"order_books"`
+	}
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return fmt.Errorf("failed to parse order books: %w", err)
+	}
+
+	markets := make(map[string]lighterMarket, len(resp.OrderBooks))
+	for _, book := range resp.OrderBooks {
+		if book.Status != "" && book.Status != "active" {
+			continue
+		}
+		markets[strings.ToUpper(book.Symbol)] = book
+	}
+
+	e.marketsMu.Lock()
+	e.markets = markets
+	e.marketsFetchedAt = time.Now()
+	e.marketsMu.Unlock()
+
+	log.Printf("Lighter: discovered %d markets", len(markets))
+	return nil
+}
+
+// mapLighterOrderStatus maps Lighter order states to the statuses used in the orders table
+func mapLighterOrderStatus(order *lighterOrder) string {
+	filled, _ := strconv.ParseFloat(order.FilledBaseAmount, 64)
+	switch {
+	case order.Status == "filled":
+		return "FILLED"
+	case strings.HasPrefix(order.Status, "canceled"):
+		return "CANCELLED"
+	case order.Status == "open" || order.Status == "pending":
+		if filled > 0 {
+			return "PARTIALLY_FILLED"
+		}
+		return "NEW"
+	default:
+		return strings.ToUpper(order.Status)
 	}
-	return 1 // Default to BTC market
 }
@@ -0,0 +1,111 @@
+package exchange
+
+import (
+	"context"
+	"crypto-sync-bot/internal/config"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+// fakeLighter serves one market with one resting order and records sent transactions
+type fakeLighter struct {
+	mu    sync.Mutex
+	order lighterOrder
+	txs   []map[string]interface{}
+}
+
+func (f *fakeLighter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch r.URL.Path {
+	case "/api/v1/orderBooks":
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"order_books": []lighterMarket{{Symbol: "BTC", MarketID: 1, Status: "active"}},
+		})
+	case "/api/v1/accountActiveOrders":
+		json.NewEncoder(w).Encode(map[string]interface{}{"orders": []lighterOrder{f.order}})
+	case "/api/v1/accountInactiveOrders":
+		json.NewEncoder(w).Encode(map[string]interface{}{"orders": []lighterOrder{}})
+	case "/api/v1/sendTx":
+		var tx map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&tx)
+		f.txs = append(f.txs, tx)
+		fmt.Fprintf(w, `{"result":{"tx_hash":"0xabc%d"}}`, len(f.txs))
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func TestLighterOrderIndexIDs(t *testing.T) {
+	clientID := "csb0123456789abcdef0123456789abc"
+	fake := &fakeLighter{order: lighterOrder{
+		OrderIndex:        281474976710700,
+		ClientOrderIndex:  lighterClientIndex(clientID),
+		Status:            "open",
+		Type:              "limit",
+		InitialBaseAmount: "1",
+		FilledBaseAmount:  "0.25",
+	}}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	e := NewLighterExecutor(config.AccountConfig{BaseURL: server.URL, SettleInUSDC: true})
+	ctx := context.Background()
+
+	// The ID recovered by client order ID must work with the other order methods
+	found, err := e.GetOrderByClientID(ctx, clientID, "BTC-USDC")
+	if err != nil {
+		t.Fatalf("GetOrderByClientID: %v", err)
+	}
+	if want := strconv.FormatInt(fake.order.OrderIndex, 10); found.OrderID != want {
+		t.Fatalf("GetOrderByClientID returned ID %q, want order index %q", found.OrderID, want)
+	}
+
+	order, err := e.GetOrder(ctx, found.OrderID, "BTC-USDC")
+	if err != nil {
+		t.Fatalf("GetOrder(%s): %v", found.OrderID, err)
+	}
+	if order.Status != "PARTIALLY_FILLED" || order.FilledQuantity != 0.25 {
+		t.Errorf("GetOrder = %s filled %v, want PARTIALLY_FILLED filled 0.25", order.Status, order.FilledQuantity)
+	}
+
+	if err := e.CancelOrder(ctx, found.OrderID, "BTC-USDC"); err != nil {
+		t.Fatalf("CancelOrder(%s): %v", found.OrderID, err)
+	}
+	if len(fake.txs) != 1 || fake.txs[0]["tx_type"] != "CancelOrder" {
+		t.Fatalf("sent %v, want one CancelOrder", fake.txs)
+	}
+	info := fake.txs[0]["tx_info"].(map[string]interface{})
+	if index := int64(info["index"].(float64)); index != fake.order.OrderIndex || info["market_id"].(float64) != 1 {
+		t.Errorf("CancelOrder sent %v, want index %d in market 1", info, fake.order.OrderIndex)
+	}
+
+	if _, err := e.GetOrder(ctx, "12345", "BTC-USDC"); err == nil {
+		t.Error("GetOrder of an unknown order index succeeded")
+	}
+}
+
+func TestLighterOrderIndex(t *testing.T) {
+	tests := []struct {
+		id     string
+		want   int64
+		wantOK bool
+	}{
+		{"281474976710700", 281474976710700, true},
+		{"0", 0, true},
+		{"0x5f2c0d8a9b7e6f1c3d4e5a6b7c8d9e0f1a2b3c4d5e6f7a8b9c0d1e2f3a4b5c6d", 0, false},
+		{"5f2c0d8a9b7e6f1c3d4e5a6b7c8d9e0f1a2b3c4d5e6f7a8b9c0d1e2f3a4b5c6d", 0, false},
+		{"98765432109876543210987654321098765432109876543210987654321098", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := lighterOrderIndex(tt.id)
+		if ok != tt.wantOK || (ok && got != tt.want) {
+			t.Errorf("lighterOrderIndex(%q) = %d, %v; want %d, %v", tt.id, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
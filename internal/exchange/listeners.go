@@ -0,0 +1,64 @@
+package exchange
+
+import (
+	"crypto-sync-bot/internal/config"
+	"log"
+	"strings"
+	"sync"
+)
+
+// Listener watches a lead account and produces trading signals into the stream
+type Listener interface {
+	Name() string
+	Start() error
+	Stop()
+}
+
+// ListenerFactory builds a listener for one account. cfg gives access to
+// settings shared by all accounts, such as sync items.
+// It returns (nil, nil) when the account has no credentials.
+type ListenerFactory func(account config.AccountConfig, cfg *config.Config) (Listener, error)
+
+var (
+	listenerRegistryMu sync.RWMutex
+	listenerRegistry   = make(map[string]ListenerFactory)
+)
+
+// RegisterListener makes a signal source available for the given exchange type.
+// Listeners call this from init() in their own file.
+func RegisterListener(id string, factory ListenerFactory) {
+	listenerRegistryMu.Lock()
+	defer listenerRegistryMu.Unlock()
+	if _, exists := listenerRegistry[id]; exists {
+		panic("exchange: listener registered twice: " + id)
+	}
+	listenerRegistry[id] = factory
+}
+
+// BuildListeners creates a listener for every configured account on an exchange
+// that supports one, keyed by account ID. Listeners that fail to build or
+// accounts without credentials are skipped.
+func BuildListeners(cfg *config.Config) map[string]Listener {
+	listeners := make(map[string]Listener)
+	for _, account := range cfg.GetAccounts() {
+		id := strings.ToLower(account.ID)
+
+		listenerRegistryMu.RLock()
+		factory, ok := listenerRegistry[account.Exchange]
+		listenerRegistryMu.RUnlock()
+		if !ok {
+			continue
+		}
+
+		listener, err := factory(account, cfg)
+		if err != nil {
+			log.Printf("Warning: %s listener disabled: %v", id, err)
+			continue
+		}
+		if listener == nil {
+			continue
+		}
+		listeners[id] = listener
+	}
+	return listeners
+}
@@ -0,0 +1,133 @@
+package exchange
+
+import (
+	"context"
+	"crypto-sync-bot/internal/config"
+	"crypto-sync-bot/internal/models"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+// fakeOKX serves one swap instrument in the given position mode and records placed orders
+type fakeOKX struct {
+	mu        sync.Mutex
+	posMode   string
+	positions []okxPosition
+	orders    []map[string]interface{}
+	simulated []string // x-simulated-trading header of each request
+}
+
+func (f *fakeOKX) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.simulated = append(f.simulated, r.Header.Get("x-simulated-trading"))
+
+	var data interface{}
+	switch r.URL.Path {
+	case "/api/v5/public/instruments":
+		data = []map[string]string{{"ctVal": "0.01", "lotSz": "0.01", "minSz": "0.01", "tickSz": "0.1"}}
+	case "/api/v5/account/config":
+		data = []map[string]string{{"posMode": f.posMode}}
+	case "/api/v5/account/positions":
+		data = f.positions
+	case "/api/v5/trade/order":
+		var order map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&order)
+		f.orders = append(f.orders, order)
+		data = []map[string]string{{"ordId": fmt.Sprint(len(f.orders)), "sCode": "0"}}
+	default:
+		http.NotFound(w, r)
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{"code": "0", "data": data})
+}
+
+func TestOKXPlaceOrderPositionSide(t *testing.T) {
+	tests := []struct {
+		name      string
+		posMode   string
+		positions []okxPosition
+		side      string
+		quantity  float64
+		want      string // posSide sent, empty for none
+	}{
+		{name: "net mode", posMode: "net_mode", positions: []okxPosition{{Pos: "-5", PosSide: "net"}}, side: "BUY", quantity: 0.02},
+		{name: "open long", posMode: okxLongShortMode, side: "BUY", quantity: 0.02, want: "long"},
+		{name: "open short", posMode: okxLongShortMode, side: "SELL", quantity: 0.02, want: "short"},
+		{name: "close short", posMode: okxLongShortMode, positions: []okxPosition{{Pos: "5", PosSide: "short"}}, side: "BUY", quantity: 0.02, want: "short"},
+		{name: "close long", posMode: okxLongShortMode, positions: []okxPosition{{Pos: "2", PosSide: "long"}}, side: "SELL", quantity: 0.02, want: "long"},
+		{name: "beyond the opposite leg", posMode: okxLongShortMode, positions: []okxPosition{{Pos: "1", PosSide: "long"}}, side: "SELL", quantity: 0.02, want: "short"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &fakeOKX{posMode: tt.posMode, positions: tt.positions}
+			server := httptest.NewServer(fake)
+			defer server.Close()
+
+			e := NewOKXExecutor(config.AccountConfig{BaseURL: server.URL})
+			res, err := e.PlaceOrder(context.Background(), &models.TradingSignal{
+				Symbol: "BTC-USDT", Side: tt.side, OrderType: "MARKET", Quantity: tt.quantity,
+			})
+			if err != nil {
+				t.Fatalf("PlaceOrder: %v", err)
+			}
+			if res.Status != "success" || len(fake.orders) != 1 {
+				t.Fatalf("PlaceOrder = %+v with %d order(s), want one successful order", res, len(fake.orders))
+			}
+			got, _ := fake.orders[0]["posSide"].(string)
+			if got != tt.want {
+				t.Errorf("posSide = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOKXPlaceOrderBelowMinimum(t *testing.T) {
+	fake := &fakeOKX{posMode: "net_mode"}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	e := NewOKXExecutor(config.AccountConfig{BaseURL: server.URL})
+	res, err := e.PlaceOrder(context.Background(), &models.TradingSignal{
+		Symbol: "BTC-USDT", Side: "BUY", OrderType: "MARKET", Quantity: 0.00001,
+	})
+	if err != nil {
+		t.Fatalf("PlaceOrder below the minimum returned error %v, want a skipped result", err)
+	}
+	if res.Status != "skipped" || res.ErrorMessage == "" {
+		t.Errorf("PlaceOrder = %+v, want status skipped with a reason", res)
+	}
+	if len(fake.orders) != 0 {
+		t.Errorf("placed %d order(s) below the minimum", len(fake.orders))
+	}
+}
+
+func TestOKXTestnetHeader(t *testing.T) {
+	for _, testnet := range []bool{false, true} {
+		fake := &fakeOKX{posMode: "net_mode"}
+		server := httptest.NewServer(fake)
+
+		e := NewOKXExecutor(config.AccountConfig{BaseURL: server.URL, Testnet: testnet})
+		if _, err := e.PlaceOrder(context.Background(), &models.TradingSignal{
+			Symbol: "BTC-USDT", Side: "BUY", OrderType: "MARKET", Quantity: 0.01,
+		}); err != nil {
+			t.Fatalf("PlaceOrder: %v", err)
+		}
+		server.Close()
+
+		want := ""
+		if testnet {
+			want = "1"
+		}
+		for i, got := range fake.simulated {
+			if got != want {
+				t.Errorf("testnet=%v: request %d sent x-simulated-trading %q, want %q", testnet, i, got, want)
+			}
+		}
+	}
+}
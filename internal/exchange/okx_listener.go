@@ -0,0 +1,308 @@
+package exchange
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"crypto-sync-bot/internal/config"
+	"crypto-sync-bot/internal/models"
+	"crypto-sync-bot/internal/processor"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	okxPrivateWSURL     = "wss://ws.okx.com:8443/ws/v5/private"
+	okxDemoPrivateWSURL = "wss://wspap.okx.com:8443/ws/v5/private"
+	okxLoginTimeout     = 10 * time.Second
+)
+
+func init() {
+	RegisterListener(config.ExchangeOKX, func(account config.AccountConfig, cfg *config.Config) (Listener, error) {
+		if account.APIKey == "" {
+			return nil, nil
+		}
+		return NewOKXListener(account), nil
+	})
+}
+
+// OKXListener subscribes to the OKX private orders channel and produces a
+// signal for every filled swap order.
+type OKXListener struct {
+	rest     *OKXExecutor // REST access for contract values and balances
+	account  config.AccountConfig
+	mu       sync.Mutex
+	running  bool
+	conn     *websocket.Conn
+	stopChan chan struct{}
+}
+
+func NewOKXListener(account config.AccountConfig) *OKXListener {
+	return &OKXListener{
+		rest:     NewOKXExecutor(account),
+		account:  account,
+		stopChan: make(chan struct{}),
+	}
+}
+
+func (l *OKXListener) Name() string {
+	return "OKX"
+}
+
+func (l *OKXListener) Start() error {
+	l.mu.Lock()
+	if l.running {
+		l.mu.Unlock()
+		return nil
+	}
+	l.running = true
+	l.mu.Unlock()
+
+	go l.connectWebSocket()
+	return nil
+}
+
+func (l *OKXListener) connectWebSocket() {
+	for {
+		select {
+		case <-l.stopChan:
+			return
+		default:
+		}
+
+		if err := l.serve(); err != nil {
+			log.Printf("OKX WebSocket Error: %v", err)
+		}
+
+		select {
+		case <-l.stopChan:
+			return
+		case <-time.After(2 * time.Second):
+			log.Println("OKX WebSocket disconnected, reconnecting...")
+		}
+	}
+}
+
+// wsURL returns the private stream endpoint; demo trading accounts are only
+// served by the demo host
+func (l *OKXListener) wsURL() string {
+	if l.account.Testnet {
+		return okxDemoPrivateWSURL
+	}
+	return okxPrivateWSURL
+}
+
+// serve runs one private WebSocket session until it drops or the listener stops
+func (l *OKXListener) serve() error {
+	conn, _, err := websocket.DefaultDialer.Dial(l.wsURL(), nil)
+	if err != nil {
+		return fmt.Errorf("failed to connect: %w", err)
+	}
+	l.mu.Lock()
+	l.conn = conn
+	l.mu.Unlock()
+	defer func() {
+		l.mu.Lock()
+		l.conn = nil
+		l.mu.Unlock()
+		conn.Close()
+	}()
+
+	if err := conn.WriteJSON(l.loginRequest()); err != nil {
+		return fmt.Errorf("failed to send login: %w", err)
+	}
+	// Private channels reject subscriptions sent before login completes
+	if err := awaitOKXLogin(conn); err != nil {
+		return err
+	}
+	if err := conn.WriteJSON(map[string]interface{}{
+		"op":   "subscribe",
+		"args": []map[string]string{{"channel": "orders", "instType": "SWAP"}},
+	}); err != nil {
+		return fmt.Errorf("failed to subscribe to orders: %w", err)
+	}
+	log.Println("Starting OKX private order stream")
+
+	// OKX drops idle connections after 30s, so ping well within that window
+	var writeMu sync.Mutex
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		ticker := time.NewTicker(20 * time.Second)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				writeMu.Lock()
+				err := conn.WriteMessage(websocket.TextMessage, []byte("ping"))
+				writeMu.Unlock()
+				if err != nil {
+					return
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	for {
+		conn.SetReadDeadline(time.Now().Add(60 * time.Second))
+		_, message, err := conn.ReadMessage()
+		if err != nil {
+			select {
+			case <-l.stopChan:
+				return nil
+			default:
+				return err
+			}
+		}
+		if string(message) == "pong" {
+			continue
+		}
+		l.handleMessage(message)
+	}
+}
+
+func (l *OKXListener) loginRequest() map[string]interface{} {
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(l.account.APISecret))
+	mac.Write([]byte(timestamp + "GET" + "/users/self/verify"))
+
+	return map[string]interface{}{
+		"op": "login",
+		"args": []map[string]string{{
+			"apiKey":     l.account.APIKey,
+			"passphrase": l.account.Passphrase,
+			"timestamp":  timestamp,
+			"sign":       base64.StdEncoding.EncodeToString(mac.Sum(nil)),
+		}},
+	}
+}
+
+// awaitOKXLogin reads until OKX answers the login request, and fails unless it succeeded
+func awaitOKXLogin(conn *websocket.Conn) error {
+	conn.SetReadDeadline(time.Now().Add(okxLoginTimeout))
+	for {
+		_, message, err := conn.ReadMessage()
+		if err != nil {
+			return fmt.Errorf("no login response: %w", err)
+		}
+		var resp struct {
+			Event string `json:"event"`
+			Code  string `json:"code"`
+			Msg   string `json:"msg"`
+		}
+		if err := json.Unmarshal(message, &resp); err != nil {
+			continue
+		}
+		switch {
+		case resp.Event == "login" && resp.Code == "0":
+			return nil
+		case resp.Event == "login", resp.Event == "error":
+			return fmt.Errorf("login failed: %s %s", resp.Code, resp.Msg)
+		}
+	}
+}
+
+func (l *OKXListener) handleMessage(message []byte) {
+	var msg struct {
+		Event string `json:"event"`
+		Code  string `json:"code"`
+		Msg   string `json:"msg"`
+		Arg   struct {
+			Channel string `json:"channel"`
+		} `json:"arg"`
+		Data []struct {
+			InstID    string `json:"instId"`
+			OrdID     string `json:"ordId"`
+			State     string `json:"state"`
+			Side      string `json:"side"`
+			OrdType   string `json:"ordType"`
+			AccFillSz string `json:"accFillSz"`
+			AvgPx     string `json:"avgPx"`
+			UTime     string `json:"uTime"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(message, &msg); err != nil {
+		log.Printf("Error parsing OKX message: %v", err)
+		return
+	}
+
+	if msg.Event == "error" {
+		log.Printf("OKX WebSocket error %s: %s", msg.Code, msg.Msg)
+		return
+	}
+	if msg.Arg.Channel != "orders" {
+		return
+	}
+
+	for _, order := range msg.Data {
+		if order.State != "filled" {
+			continue
+		}
+
+		symbol, err := okxSymbols{}.FromExchange(order.InstID)
+		if err != nil {
+			log.Printf("Ignoring OKX fill with unknown symbol: %v", err)
+			continue
+		}
+		inst, err := l.rest.getInstrument(context.Background(), order.InstID)
+		if err != nil {
+			log.Printf("Error fetching OKX instrument %s: %v", order.InstID, err)
+			continue
+		}
+		contracts, err := parseFloat(order.AccFillSz)
+		if err != nil {
+			log.Printf("Error parsing Quantity from OKX: %v", err)
+			continue
+		}
+		price, err := parseFloat(order.AvgPx)
+		if err != nil {
+			log.Printf("Error parsing Price from OKX: %v", err)
+			continue
+		}
+		timestamp, _ := strconv.ParseInt(order.UTime, 10, 64)
+
+		signal := &models.TradingSignal{
+			SignalID:  order.OrdID,
+			Symbol:    symbol.String(),
+			Side:      strings.ToUpper(order.Side),
+			OrderType: strings.ToUpper(order.OrdType),
+			Quantity:  contracts * inst.CtVal, // OKX reports contracts
+			Price:     price,
+			Timestamp: timestamp,
+			Source:    l.account.ID,
+		}
+		if err := processor.ProduceSignal(context.Background(), signal); err != nil {
+			log.Printf("Error producing signal from OKX: %v", err)
+		}
+	}
+}
+
+// GetBalance returns the account equity in asset
+func (l *OKXListener) GetBalance(ctx context.Context, asset string) (float64, error) {
+	return l.rest.GetBalance(ctx, asset)
+}
+
+// GetPosition returns the net swap position in symbol
+func (l *OKXListener) GetPosition(ctx context.Context, symbol string) (*models.Position, error) {
+	return l.rest.GetPosition(ctx, symbol)
+}
+
+func (l *OKXListener) Stop() {
+	close(l.stopChan)
+	l.mu.Lock()
+	if l.conn != nil {
+		l.conn.Close()
+	}
+	l.mu.Unlock()
+}
@@ -0,0 +1,86 @@
+package exchange
+
+import (
+	"crypto-sync-bot/internal/config"
+	"crypto-sync-bot/internal/models"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sony/gobreaker"
+)
+
+// ExecutorFactory builds an executor for one account.
+// It returns (nil, nil) when the account has no credentials.
+type ExecutorFactory func(account config.AccountConfig) (models.ExchangeExecutor, error)
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]ExecutorFactory)
+)
+
+// RegisterExecutor makes an executor available for the given exchange type
+// (e.g. "okx"). Executors call this from init() in their own file.
+func RegisterExecutor(id string, factory ExecutorFactory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if _, exists := registry[id]; exists {
+		panic("exchange: executor registered twice: " + id)
+	}
+	registry[id] = factory
+}
+
+// RegisteredExecutors returns the exchange types with a registered executor in sorted order
+func RegisteredExecutors() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	ids := make([]string, 0, len(registry))
+	for id := range registry {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// BuildExecutors creates an executor for every configured account, wrapped in a
+// circuit breaker and sharing one instrument cache, keyed by account ID.
+// Accounts whose executor fails to build or that have no credentials are skipped.
+func BuildExecutors(cfg *config.Config) map[string]models.ExchangeExecutor {
+	executors := make(map[string]models.ExchangeExecutor)
+	instruments := NewInstrumentService(time.Hour)
+	for _, account := range cfg.GetAccounts() {
+		id := strings.ToLower(account.ID)
+
+		registryMu.RLock()
+		factory, ok := registry[account.Exchange]
+		registryMu.RUnlock()
+		if !ok {
+			log.Printf("Warning: account %s has unsupported exchange %q", id, account.Exchange)
+			continue
+		}
+		if account.Testnet && !config.TestnetSupported(account.Exchange) {
+			// Trading on mainnet instead would be worse than not trading
+			log.Printf("Warning: %s executor disabled: %s has no testnet", id, account.Exchange)
+			continue
+		}
+
+		raw, err := factory(account)
+		if err != nil {
+			log.Printf("Warning: %s executor disabled: %v", id, err)
+			continue
+		}
+		if raw == nil {
+			log.Printf("Note: %s executor not configured (API keys can be set via admin panel)", id)
+			continue
+		}
+
+		cb := gobreaker.NewCircuitBreaker(gobreaker.Settings{
+			Name:         id,
+			IsSuccessful: IsSuccessful,
+		})
+		executors[id] = NewResilientExecutor(raw, cb).WithInstruments(instruments)
+	}
+	return executors
+}
@@ -0,0 +1,28 @@
+package exchange
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// defaultRequestTimeout bounds exchange requests made with a context that has
+// no deadline of its own
+const defaultRequestTimeout = 30 * time.Second
+
+// requestContext applies defaultRequestTimeout to ctx unless it already has a deadline
+func requestContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if _, ok := ctx.Deadline(); ok {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, defaultRequestTimeout)
+}
+
+// contextTransport binds every request to ctx, for client libraries that take no context
+type contextTransport struct {
+	ctx context.Context
+}
+
+func (t contextTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return http.DefaultTransport.RoundTrip(req.WithContext(t.ctx))
+}
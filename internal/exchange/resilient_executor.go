@@ -1,8 +1,12 @@
 package exchange
 
 import (
+	"context"
 	"crypto-sync-bot/internal/models"
+	"errors"
+	"fmt"
 	"github.com/sony/gobreaker"
+	"log"
 	"net"
 	"strings"
 )
@@ -13,6 +17,16 @@ func IsSuccessful(err error) bool {
 		return true
 	}
 
+	// Cancelled by us (e.g. shutdown), not a sign the exchange is unhealthy
+	if errors.Is(err, context.Canceled) {
+		return true
+	}
+
+	// A definite answer from the exchange, e.g. to an in-flight order lookup
+	if errors.Is(err, models.ErrOrderNotFound) {
+		return true
+	}
+
 	// Check for network errors
 	if _, ok := err.(net.Error); ok {
 		return false
@@ -32,8 +46,9 @@ func IsSuccessful(err error) bool {
 }
 
 type ResilientExecutor struct {
-	executor models.ExchangeExecutor
-	cb       *gobreaker.CircuitBreaker
+	executor    models.ExchangeExecutor
+	cb          *gobreaker.CircuitBreaker
+	instruments *InstrumentService
 }
 
 func NewResilientExecutor(executor models.ExchangeExecutor, cb *gobreaker.CircuitBreaker) *ResilientExecutor {
@@ -43,13 +58,69 @@ func NewResilientExecutor(executor models.ExchangeExecutor, cb *gobreaker.Circui
 	}
 }
 
+// WithInstruments enables lot-size and tick-size rounding before orders are
+// placed, for executors that implement InstrumentProvider.
+func (r *ResilientExecutor) WithInstruments(instruments *InstrumentService) *ResilientExecutor {
+	r.instruments = instruments
+	return r
+}
+
 func (r *ResilientExecutor) Name() string {
 	return r.executor.Name()
 }
 
-func (r *ResilientExecutor) PlaceOrder(signal *models.TradingSignal) (*models.OrderResult, error) {
-	result, err := r.cb.Execute(func() (interface{}, error) {
-		return r.executor.PlaceOrder(signal)
+// execute runs call through the circuit breaker, unless ctx is already done
+// so that an abandoned call does not take up a half-open probe
+func (r *ResilientExecutor) execute(ctx context.Context, call func() (interface{}, error)) (interface{}, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return r.cb.Execute(call)
+}
+
+func (r *ResilientExecutor) PlaceOrder(ctx context.Context, signal *models.TradingSignal) (*models.OrderResult, error) {
+	result, err := r.execute(ctx, func() (interface{}, error) {
+		rounded, skipped, err := r.normalize(ctx, signal)
+		if rounded == nil {
+			return skipped, err
+		}
+		return r.executor.PlaceOrder(ctx, rounded)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result.(*models.OrderResult), nil
+}
+
+// normalize rounds signal to the instrument's lot and tick sizes when
+// instruments are enabled. It returns a skipped result instead of a signal
+// when the order falls below the exchange minimums.
+func (r *ResilientExecutor) normalize(ctx context.Context, signal *models.TradingSignal) (*models.TradingSignal, *models.OrderResult, error) {
+	provider, ok := r.executor.(InstrumentProvider)
+	if r.instruments == nil || !ok {
+		return signal, nil, nil
+	}
+
+	rounded, reason, err := r.instruments.Normalize(ctx, r.executor.Name(), provider, signal)
+	if err != nil {
+		return nil, nil, err
+	}
+	if reason != "" {
+		log.Printf("%s order skipped: %s", r.executor.Name(), reason)
+		return nil, &models.OrderResult{
+			Exchange:     r.executor.Name(),
+			Symbol:       signal.Symbol,
+			Status:       "skipped",
+			ErrorMessage: reason,
+			Timestamp:    signal.Timestamp,
+		}, nil
+	}
+	return rounded, nil, nil
+}
+
+func (r *ResilientExecutor) GetOrder(ctx context.Context, orderID, symbol string) (*models.OrderResult, error) {
+	result, err := r.execute(ctx, func() (interface{}, error) {
+		return r.executor.GetOrder(ctx, orderID, symbol)
 	})
 	if err != nil {
 		return nil, err
@@ -57,9 +128,42 @@ func (r *ResilientExecutor) PlaceOrder(signal *models.TradingSignal) (*models.Or
 	return result.(*models.OrderResult), nil
 }
 
-func (r *ResilientExecutor) GetOrder(orderID, symbol string) (*models.OrderResult, error) {
-	result, err := r.cb.Execute(func() (interface{}, error) {
-		return r.executor.GetOrder(orderID, symbol)
+// SupportsClientIDLookup reports whether the wrapped executor implements models.OrderLookup
+func (r *ResilientExecutor) SupportsClientIDLookup() bool {
+	_, ok := r.executor.(models.OrderLookup)
+	return ok
+}
+
+// GetOrderByClientID forwards to the wrapped executor if it supports client order ID lookups
+func (r *ResilientExecutor) GetOrderByClientID(ctx context.Context, clientOrderID, symbol string) (*models.OrderResult, error) {
+	lookup, ok := r.executor.(models.OrderLookup)
+	if !ok {
+		return nil, fmt.Errorf("%s does not support client order ID lookups", r.executor.Name())
+	}
+	result, err := r.execute(ctx, func() (interface{}, error) {
+		return lookup.GetOrderByClientID(ctx, clientOrderID, symbol)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result.(*models.OrderResult), nil
+}
+
+func (r *ResilientExecutor) CancelOrder(ctx context.Context, orderID, symbol string) error {
+	_, err := r.execute(ctx, func() (interface{}, error) {
+		return nil, r.executor.CancelOrder(ctx, orderID, symbol)
+	})
+	return err
+}
+
+// AmendOrder rounds the new price and quantity like PlaceOrder does
+func (r *ResilientExecutor) AmendOrder(ctx context.Context, orderID string, signal *models.TradingSignal) (*models.OrderResult, error) {
+	result, err := r.execute(ctx, func() (interface{}, error) {
+		rounded, skipped, err := r.normalize(ctx, signal)
+		if rounded == nil {
+			return skipped, err
+		}
+		return r.executor.AmendOrder(ctx, orderID, rounded)
 	})
 	if err != nil {
 		return nil, err
@@ -67,6 +171,32 @@ func (r *ResilientExecutor) GetOrder(orderID, symbol string) (*models.OrderResul
 	return result.(*models.OrderResult), nil
 }
 
+// SupportsConditionalOrders forwards the capability of the wrapped executor
+func (r *ResilientExecutor) SupportsConditionalOrders() bool {
+	conditional, ok := r.executor.(models.ConditionalOrderSupport)
+	return ok && conditional.SupportsConditionalOrders()
+}
+
+func (r *ResilientExecutor) GetBalance(ctx context.Context, asset string) (float64, error) {
+	result, err := r.execute(ctx, func() (interface{}, error) {
+		return r.executor.GetBalance(ctx, asset)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return result.(float64), nil
+}
+
+func (r *ResilientExecutor) GetPosition(ctx context.Context, symbol string) (*models.Position, error) {
+	result, err := r.execute(ctx, func() (interface{}, error) {
+		return r.executor.GetPosition(ctx, symbol)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result.(*models.Position), nil
+}
+
 func (r *ResilientExecutor) Close() {
 	r.executor.Close()
 }
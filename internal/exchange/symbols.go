@@ -0,0 +1,146 @@
+package exchange
+
+import (
+	"crypto-sync-bot/internal/models"
+	"fmt"
+	"strings"
+)
+
+// SymbolTranslator converts between canonical symbols and an exchange's native format
+type SymbolTranslator interface {
+	ToExchange(symbol models.Symbol) (string, error)
+	FromExchange(native string) (models.Symbol, error)
+}
+
+// nativeSymbol parses a canonical (or loosely formatted) symbol and translates it for one exchange
+func nativeSymbol(t SymbolTranslator, symbol string) (string, error) {
+	parsed, err := models.ParseSymbol(symbol)
+	if err != nil {
+		return "", err
+	}
+	return t.ToExchange(parsed)
+}
+
+// requirePerpetual rejects spot symbols on executors that only trade perpetuals
+func requirePerpetual(exchange string, symbol models.Symbol) error {
+	if symbol.Contract != models.ContractPerpetual {
+		return fmt.Errorf("%s: %s contracts are not supported for %s", exchange, symbol.Contract, symbol)
+	}
+	return nil
+}
+
+// binanceSymbols maps to USDⓈ-M futures symbols: BTC-USDT -> BTCUSDT
+type binanceSymbols struct{}
+
+func (binanceSymbols) ToExchange(symbol models.Symbol) (string, error) {
+	if err := requirePerpetual("binance", symbol); err != nil {
+		return "", err
+	}
+	if symbol.Quote != "USDT" && symbol.Quote != "USDC" {
+		return "", fmt.Errorf("binance: no USDⓈ-M market for %s", symbol)
+	}
+	return symbol.Base + symbol.Quote, nil
+}
+
+func (binanceSymbols) FromExchange(native string) (models.Symbol, error) {
+	return models.ParseSymbol(native)
+}
+
+// okxSymbols maps to swap instrument IDs: BTC-USDT -> BTC-USDT-SWAP
+type okxSymbols struct{}
+
+func (okxSymbols) ToExchange(symbol models.Symbol) (string, error) {
+	if err := requirePerpetual("okx", symbol); err != nil {
+		return "", err
+	}
+	if symbol.Quote != "USDT" && symbol.Quote != "USDC" && symbol.Quote != "USD" {
+		return "", fmt.Errorf("okx: no swap market for %s", symbol)
+	}
+	return symbol.Base + "-" + symbol.Quote + "-SWAP", nil
+}
+
+func (okxSymbols) FromExchange(native string) (models.Symbol, error) {
+	if !strings.HasSuffix(native, "-SWAP") {
+		return models.Symbol{}, fmt.Errorf("okx: %q is not a swap instrument", native)
+	}
+	return models.ParseSymbol(native)
+}
+
+// bybitSymbols maps to linear contracts: BTC-USDT -> BTCUSDT, BTC-USDC -> BTCPERP
+type bybitSymbols struct{}
+
+func (bybitSymbols) ToExchange(symbol models.Symbol) (string, error) {
+	if err := requirePerpetual("bybit", symbol); err != nil {
+		return "", err
+	}
+	switch symbol.Quote {
+	case "USDT":
+		return symbol.Base + "USDT", nil
+	case "USDC":
+		return symbol.Base + "PERP", nil
+	}
+	return "", fmt.Errorf("bybit: no linear market for %s", symbol)
+}
+
+func (bybitSymbols) FromExchange(native string) (models.Symbol, error) {
+	if base := strings.TrimSuffix(native, "PERP"); base != native && base != "" {
+		return models.Symbol{Base: base, Quote: "USDC", Contract: models.ContractPerpetual}, nil
+	}
+	return models.ParseSymbol(native)
+}
+
+// usdcPerpetual checks that symbol can trade on a venue that only lists USDC
+// perpetuals. Other stablecoin quotes are a different instrument, so they are
+// accepted only when the account opts in to trading them against USDC.
+func usdcPerpetual(exchange string, symbol models.Symbol, stablecoinsAsUSDC bool) error {
+	if err := requirePerpetual(exchange, symbol); err != nil {
+		return err
+	}
+	if symbol.Quote == "USDC" {
+		return nil
+	}
+	if stablecoinsAsUSDC && models.IsStablecoin(symbol.Quote) {
+		return nil
+	}
+	if models.IsStablecoin(symbol.Quote) {
+		return fmt.Errorf("%s: no %s perpetual market for %s (set settle_in_usdc to trade it against USDC)", exchange, symbol.Quote, symbol)
+	}
+	return fmt.Errorf("%s: no perpetual market for %s", exchange, symbol)
+}
+
+// backpackSymbols maps to USDC-margined perps: BTC-USDC -> BTC_USDC_PERP.
+// With stablecoinsAsUSDC, BTC-USDT maps to the same market.
+type backpackSymbols struct {
+	stablecoinsAsUSDC bool
+}
+
+func (t backpackSymbols) ToExchange(symbol models.Symbol) (string, error) {
+	if err := usdcPerpetual("backpack", symbol, t.stablecoinsAsUSDC); err != nil {
+		return "", err
+	}
+	return symbol.Base + "_USDC_PERP", nil
+}
+
+func (backpackSymbols) FromExchange(native string) (models.Symbol, error) {
+	return models.ParseSymbol(native)
+}
+
+// lighterSymbols maps to Lighter's base-asset market names: BTC-USDC -> BTC.
+// Lighter perpetuals are USDC-margined; with stablecoinsAsUSDC, BTC-USDT maps to BTC too.
+type lighterSymbols struct {
+	stablecoinsAsUSDC bool
+}
+
+func (t lighterSymbols) ToExchange(symbol models.Symbol) (string, error) {
+	if err := usdcPerpetual("lighter", symbol, t.stablecoinsAsUSDC); err != nil {
+		return "", err
+	}
+	return symbol.Base, nil
+}
+
+func (lighterSymbols) FromExchange(native string) (models.Symbol, error) {
+	if native == "" {
+		return models.Symbol{}, fmt.Errorf("lighter: empty market symbol")
+	}
+	return models.Symbol{Base: strings.ToUpper(native), Quote: "USDC", Contract: models.ContractPerpetual}, nil
+}
@@ -1,8 +1,57 @@
 package models
 
+import (
+	"context"
+	"errors"
+)
+
+// ErrOrderNotFound is returned by OrderLookup when the exchange has no such order
+var ErrOrderNotFound = errors.New("order not found")
+
+// ExchangeExecutor places and looks up orders on one account. Every call
+// stops waiting on the exchange once ctx is done.
 type ExchangeExecutor interface {
 	Name() string
-	PlaceOrder(signal *TradingSignal) (*OrderResult, error)
-	GetOrder(orderID, symbol string) (*OrderResult, error)
+	PlaceOrder(ctx context.Context, signal *TradingSignal) (*OrderResult, error)
+	GetOrder(ctx context.Context, orderID, symbol string) (*OrderResult, error)
+	CancelOrder(ctx context.Context, orderID, symbol string) error
+	// AmendOrder moves a resting order to the signal's price and total quantity.
+	// Exchanges without native amendment cancel and replace the order, placing
+	// the rest with the signal's client order ID, so the result's OrderID may differ.
+	AmendOrder(ctx context.Context, orderID string, signal *TradingSignal) (*OrderResult, error)
+	GetBalance(ctx context.Context, asset string) (float64, error)
+	GetPosition(ctx context.Context, symbol string) (*Position, error)
 	Close()
 }
+
+// BalanceProvider reports account equity in the given asset (e.g. "USDT").
+// Signal sources implement it so targets can be sized proportionally.
+type BalanceProvider interface {
+	GetBalance(ctx context.Context, asset string) (float64, error)
+}
+
+// PositionProvider reports the net position in a symbol.
+// Signal sources implement it for position-snapshot sync.
+type PositionProvider interface {
+	GetPosition(ctx context.Context, symbol string) (*Position, error)
+}
+
+// OrderLookup finds an order by the client order ID it was placed with, so an
+// order whose placement outcome is unknown (e.g. after a crash) can be checked.
+type OrderLookup interface {
+	GetOrderByClientID(ctx context.Context, clientOrderID, symbol string) (*OrderResult, error)
+}
+
+// ClientIDLookupSupport reports whether an executor can really look orders up
+// by client order ID. Wrappers that implement OrderLookup for any executor
+// they wrap implement it to tell whether the wrapped one can.
+type ClientIDLookupSupport interface {
+	SupportsClientIDLookup() bool
+}
+
+// ConditionalOrderSupport reports whether an executor attaches the stop-loss
+// and take-profit prices of a signal to the orders it places. Executors that
+// do not implement it, or report false, place the bare order.
+type ConditionalOrderSupport interface {
+	SupportsConditionalOrders() bool
+}
@@ -0,0 +1,11 @@
+package models
+
+// Instrument describes the order size and price rules of a symbol on one exchange.
+// Quantities are in base currency; zero means the exchange imposes no constraint.
+type Instrument struct {
+	Symbol      string  `json:"symbol"`
+	MinQty      float64 `json:"min_qty"`
+	QtyStep     float64 `json:"qty_step"`
+	TickSize    float64 `json:"tick_size"`
+	MinNotional float64 `json:"min_notional"`
+}
@@ -2,10 +2,18 @@ package models
 
 import "time"
 
+// Outbox is a signal stored durably before it is published to the signal stream
 type Outbox struct {
-	ID        string    `json:"id"`
-	Payload   string    `json:"payload"`
-	Status    string    `json:"status"` // "pending", "processed", "failed"
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID            int64     `json:"id" gorm:"primaryKey;autoIncrement"`
+	Payload       string    `json:"payload" gorm:"type:text"`
+	Status        string    `json:"status" gorm:"size:16;index"` // "pending", "processed", "failed"
+	Attempts      int       `json:"attempts"`
+	LastError     string    `json:"last_error,omitempty" gorm:"type:text"`
+	NextAttemptAt time.Time `json:"next_attempt_at" gorm:"index"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+func (Outbox) TableName() string {
+	return "outbox"
 }
@@ -0,0 +1,8 @@
+package models
+
+// Position is the net position held in one symbol
+type Position struct {
+	Symbol     string  `json:"symbol"`
+	Size       float64 `json:"size"` // Base units; positive is long, negative is short
+	EntryPrice float64 `json:"entry_price,omitempty"`
+}
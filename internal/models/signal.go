@@ -1,5 +1,14 @@
 package models
 
+// Signal actions. A signal without an action mirrors a fill; a placement
+// mirrors a resting limit order; cancels and amendments apply to the target
+// orders linked to its SourceOrderID.
+const (
+	ActionPlace  = "PLACE"
+	ActionCancel = "CANCEL"
+	ActionAmend  = "AMEND"
+)
+
 type TradingSignal struct {
 	Symbol          string  `json:"symbol"`
 	Side            string  `json:"side"`        // "BUY" or "SELL"
@@ -12,13 +21,23 @@ type TradingSignal struct {
 	Timestamp       int64   `json:"timestamp"`
 	SignalID        string  `json:"signal_id"`
 	Source          string  `json:"source"` // "binance"
+	// ClientOrderID is set per target by the processor so the exchange can
+	// dedupe retried orders; executors derive their native form from it
+	ClientOrderID string `json:"client_order_id,omitempty"`
+	// Action is empty for fills, otherwise one of the Action constants
+	Action string `json:"action,omitempty"`
+	// SourceOrderID is the lead's order the signal comes from; target orders
+	// are linked to it so later cancels and amendments reach them
+	SourceOrderID string `json:"source_order_id,omitempty"`
 }
 
 type OrderResult struct {
-	Exchange     string `json:"exchange"`
-	Symbol       string `json:"symbol"`
-	OrderID      string `json:"order_id"`
-	Status       string `json:"status"` // "success" or "failed"
-	ErrorMessage string `json:"error_message"`
-	Timestamp    int64  `json:"timestamp"`
+	Exchange       string  `json:"exchange"`
+	Symbol         string  `json:"symbol"`
+	OrderID        string  `json:"order_id"`
+	Status         string  `json:"status"` // "success" or "failed"
+	ErrorMessage   string  `json:"error_message"`
+	Timestamp      int64   `json:"timestamp"`
+	FilledQuantity float64 `json:"filled_quantity,omitempty"` // Set by GetOrder when known
+	AvgPrice       float64 `json:"avg_price,omitempty"`       // Set by GetOrder when known
 }
@@ -0,0 +1,83 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+type ContractType string
+
+const (
+	ContractPerpetual ContractType = "PERP"
+	ContractSpot      ContractType = "SPOT"
+)
+
+// knownQuotes lists quote currencies recognised when a symbol has no separator
+// (BTCUSDT). Longer quotes come first so FDUSD is not read as ...USD.
+var knownQuotes = []string{"FDUSD", "USDT", "USDC", "BUSD", "USD"}
+
+// Symbol is the exchange-independent identity of a market. Executors and
+// listeners translate it to and from their native formats.
+type Symbol struct {
+	Base     string       `json:"base"`
+	Quote    string       `json:"quote"`
+	Contract ContractType `json:"contract"`
+}
+
+// String returns the canonical form: BTC-USDT for perpetuals, BTC-USDT-SPOT otherwise
+func (s Symbol) String() string {
+	if s.Contract == ContractPerpetual || s.Contract == "" {
+		return s.Base + "-" + s.Quote
+	}
+	return s.Base + "-" + s.Quote + "-" + string(s.Contract)
+}
+
+// ParseSymbol parses canonical and common exchange formats such as BTCUSDT,
+// BTC-USDT, BTC_USDT, BTC/USDT, BTC-USDT-SWAP and BTC_USDC_PERP.
+// Symbols without a contract suffix are treated as perpetuals.
+func ParseSymbol(raw string) (Symbol, error) {
+	s := strings.ToUpper(strings.TrimSpace(raw))
+	if s == "" {
+		return Symbol{}, fmt.Errorf("empty symbol")
+	}
+
+	parts := strings.FieldsFunc(s, func(r rune) bool {
+		return r == '-' || r == '_' || r == '/'
+	})
+
+	contract := ContractPerpetual
+	if len(parts) > 1 {
+		switch parts[len(parts)-1] {
+		case "SWAP", "PERP":
+			parts = parts[:len(parts)-1]
+		case "SPOT":
+			contract = ContractSpot
+			parts = parts[:len(parts)-1]
+		}
+	}
+
+	switch len(parts) {
+	case 2:
+		if parts[0] == "" || parts[1] == "" {
+			break
+		}
+		return Symbol{Base: parts[0], Quote: parts[1], Contract: contract}, nil
+	case 1:
+		for _, quote := range knownQuotes {
+			if base := strings.TrimSuffix(parts[0], quote); base != parts[0] && base != "" {
+				return Symbol{Base: base, Quote: quote, Contract: contract}, nil
+			}
+		}
+	}
+	return Symbol{}, fmt.Errorf("unrecognised symbol %q", raw)
+}
+
+// IsStablecoin reports whether the asset is a USD stablecoin, used when a venue
+// lists a market only against a different stablecoin than the source.
+func IsStablecoin(asset string) bool {
+	switch asset {
+	case "USDT", "USDC", "USD", "BUSD", "FDUSD":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,61 @@
+package models
+
+import "testing"
+
+func TestParseSymbol(t *testing.T) {
+	tests := []struct {
+		raw     string
+		want    Symbol
+		wantErr bool
+	}{
+		{raw: "BTCUSDT", want: Symbol{Base: "BTC", Quote: "USDT", Contract: ContractPerpetual}},
+		{raw: "BTC-USDT", want: Symbol{Base: "BTC", Quote: "USDT", Contract: ContractPerpetual}},
+		{raw: "btc_usdt", want: Symbol{Base: "BTC", Quote: "USDT", Contract: ContractPerpetual}},
+		{raw: " ETH/USDC ", want: Symbol{Base: "ETH", Quote: "USDC", Contract: ContractPerpetual}},
+		{raw: "BTC-USDT-SWAP", want: Symbol{Base: "BTC", Quote: "USDT", Contract: ContractPerpetual}},
+		{raw: "SOL_USDC_PERP", want: Symbol{Base: "SOL", Quote: "USDC", Contract: ContractPerpetual}},
+		{raw: "BTC-USDT-SPOT", want: Symbol{Base: "BTC", Quote: "USDT", Contract: ContractSpot}},
+		{raw: "BTCFDUSD", want: Symbol{Base: "BTC", Quote: "FDUSD", Contract: ContractPerpetual}},
+		{raw: "ETHUSD", want: Symbol{Base: "ETH", Quote: "USD", Contract: ContractPerpetual}},
+		{raw: "1000PEPEUSDT", want: Symbol{Base: "1000PEPE", Quote: "USDT", Contract: ContractPerpetual}},
+		{raw: "", wantErr: true},
+		{raw: "USDT", wantErr: true},
+		{raw: "BTCEUR", wantErr: true},
+		{raw: "BTC-", wantErr: true},
+		{raw: "A-B-C", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			got, err := ParseSymbol(tt.raw)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("ParseSymbol(%q) = %+v, want an error", tt.raw, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseSymbol(%q): %v", tt.raw, err)
+			}
+			if got != tt.want {
+				t.Errorf("ParseSymbol(%q) = %+v, want %+v", tt.raw, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSymbolString(t *testing.T) {
+	for raw, want := range map[string]string{
+		"BTCUSDT":       "BTC-USDT",
+		"BTC-USDT-SWAP": "BTC-USDT",
+		"ETH_USDC_SPOT": "ETH-USDC-SPOT",
+	} {
+		symbol, err := ParseSymbol(raw)
+		if err != nil {
+			t.Fatalf("ParseSymbol(%q): %v", raw, err)
+		}
+		if got := symbol.String(); got != want {
+			t.Errorf("ParseSymbol(%q).String() = %q, want %q", raw, got, want)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package processor
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"sync"
+)
+
+// consumerName identifies this instance within the consumer group. It has to
+// be unique per replica, and should survive restarts so the instance picks its
+// own pending signals back up.
+func consumerName() string {
+	for _, env := range []string{"CONSUMER_ID", "POD_NAME"} {
+		if name := os.Getenv(env); name != "" {
+			return name
+		}
+	}
+	if host, err := os.Hostname(); err == nil && host != "" {
+		return host
+	}
+	return "processor-1"
+}
+
+func (p *SignalProcessor) batchSize() int64 {
+	if n := p.config.GetSync().ConsumerBatchSize; n > 0 {
+		return int64(n)
+	}
+	return 10
+}
+
+func (p *SignalProcessor) workerCount() int {
+	if n := p.config.GetSync().ConsumerWorkers; n > 0 {
+		return n
+	}
+	return 4
+}
+
+type job struct {
+	msg  Message
+	done *sync.WaitGroup
+}
+
+// startWorkers launches the worker pool. Each worker runs its jobs one at a time.
+func (p *SignalProcessor) startWorkers() {
+	p.workers = make([]chan job, p.workerCount())
+	for i := range p.workers {
+		jobs := make(chan job)
+		p.workers[i] = jobs
+		p.goRun(func() {
+			ctx := context.Background()
+			for {
+				select {
+				case <-p.stopChan:
+					return
+				case j := <-jobs:
+					p.handleMessage(ctx, j.msg)
+					j.done.Done()
+				}
+			}
+		})
+	}
+}
+
+// dispatch queues msg on the worker that owns its symbol, so signals for one
+// symbol never run concurrently or out of order
+func (p *SignalProcessor) dispatch(msg Message, done *sync.WaitGroup) {
+	done.Add(1)
+	worker := p.workers[symbolHash(messageSymbol(msg.Payload))%uint32(len(p.workers))]
+	select {
+	case worker <- job{msg: msg, done: done}:
+	case <-p.stopChan:
+		done.Done()
+	}
+}
+
+// messageSymbol reads the symbol of a queued signal. Malformed payloads map to
+// the empty symbol; handleMessage rejects them.
+func messageSymbol(payload string) string {
+	var signal struct {
+		Symbol string `json:"symbol"`
+	}
+	json.Unmarshal([]byte(payload), &signal)
+	return signal.Symbol
+}
@@ -0,0 +1,165 @@
+package processor
+
+import (
+	"context"
+	"crypto-sync-bot/internal/models"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+var ErrDLQEntryNotFound = errors.New("dlq entry not found")
+
+// DLQEntry is a signal that exhausted its retries, with why it failed
+type DLQEntry struct {
+	ID         string                `json:"id"`
+	Signal     *models.TradingSignal `json:"signal,omitempty"`
+	Payload    string                `json:"payload,omitempty"` // raw payload when it cannot be decoded
+	Targets    []string              `json:"targets,omitempty"` // replay restriction the signal carried, if any
+	Errors     map[string]string     `json:"errors,omitempty"`  // last error per target account
+	Executions []TargetExecution     `json:"executions,omitempty"`
+	Reason     string                `json:"reason,omitempty"`
+	Deliveries int64                 `json:"deliveries"`
+	Stream     string                `json:"stream,omitempty"`
+	MessageID  string                `json:"message_id,omitempty"` // ID in the signal queue
+	QueuedAt   time.Time             `json:"queued_at"`
+	FailedAt   time.Time             `json:"failed_at"`
+
+	payload string // as queued, for replays
+}
+
+// newDLQEntry decodes the fields every queue stores the same way
+func newDLQEntry(id, payload, targets, errs string) DLQEntry {
+	entry := DLQEntry{ID: id, Targets: splitTargets(targets), payload: payload}
+	var signal models.TradingSignal
+	if err := json.Unmarshal([]byte(payload), &signal); err == nil {
+		entry.Signal = &signal
+	} else {
+		entry.Payload = payload
+	}
+	if errs != "" {
+		json.Unmarshal([]byte(errs), &entry.Errors)
+	}
+	return entry
+}
+
+// moveToDLQ parks a signal that has used up its retries. failures holds the
+// last error of each target; it is nil when the signal never finished
+// processing, e.g. because it kept crashing the consumer.
+func (p *SignalProcessor) moveToDLQ(ctx context.Context, msg Message, failures map[string]error) {
+	log.Printf("Signal %s failed %d times, moving to DLQ", msg.ID, msg.Deliveries)
+
+	reason := "retries exhausted before processing completed"
+	var errs map[string]string
+	if len(failures) > 0 {
+		errs = make(map[string]string, len(failures))
+		for id, err := range failures {
+			errs[id] = err.Error()
+		}
+		reason = fmt.Sprintf("failed on %d target(s)", len(failures))
+	}
+
+	if err := signalQueue.DeadLetter(ctx, msg, errs, reason); err != nil {
+		// Keep it pending rather than lose it; the next recovery pass tries again
+		log.Printf("Failed to move signal %s to DLQ: %v", msg.ID, err)
+	}
+}
+
+// ListDLQ returns up to count DLQ entries, oldest first, starting after the
+// entry ID after (empty for the beginning)
+func ListDLQ(ctx context.Context, after string, count int64) ([]DLQEntry, error) {
+	if signalQueue == nil {
+		return nil, ErrQueueUnavailable
+	}
+	entries, err := signalQueue.ListDLQ(ctx, after, count)
+	if err != nil {
+		return nil, err
+	}
+	for i := range entries {
+		entry := &entries[i]
+		if entry.Signal != nil {
+			signalKey := entry.Signal.SignalID
+			if signalKey == "" {
+				signalKey = entry.MessageID
+			}
+			entry.Executions, _ = GetExecutions(ctx, entry.Signal.Source, signalKey)
+		}
+	}
+	return entries, nil
+}
+
+// ReplayDLQ queues a DLQ entry again and removes it from the DLQ. When targets
+// is non-empty the replay only executes on those accounts. Targets that
+// already succeeded are skipped either way, see beginExecution.
+func ReplayDLQ(ctx context.Context, id string, targets []string) error {
+	if signalQueue == nil {
+		return ErrQueueUnavailable
+	}
+	entry, err := signalQueue.GetDLQ(ctx, id)
+	if err != nil {
+		return err
+	}
+	return replay(ctx, entry, targets)
+}
+
+// ReplayAllDLQ queues every DLQ entry again, each with its own target
+// restriction. Entries whose payload cannot be decoded stay in the DLQ and are
+// counted as skipped.
+func ReplayAllDLQ(ctx context.Context) (replayed, skipped int, err error) {
+	if signalQueue == nil {
+		return 0, 0, ErrQueueUnavailable
+	}
+	after := ""
+	for {
+		// Replayed entries leave the DLQ; skipped ones are paged past
+		entries, err := signalQueue.ListDLQ(ctx, after, 100)
+		if err != nil {
+			return replayed, skipped, err
+		}
+		if len(entries) == 0 {
+			return replayed, skipped, nil
+		}
+		for i := range entries {
+			entry := &entries[i]
+			after = entry.ID
+			if entry.Signal == nil {
+				log.Printf("Skipping DLQ entry %s: invalid payload", entry.ID)
+				skipped++
+				continue
+			}
+			if err := replay(ctx, entry, entry.Targets); err != nil {
+				return replayed, skipped, err
+			}
+			replayed++
+		}
+	}
+}
+
+func replay(ctx context.Context, entry *DLQEntry, targets []string) error {
+	if entry.Signal == nil {
+		return fmt.Errorf("dlq entry %s has an invalid payload", entry.ID)
+	}
+	if err := signalQueue.Requeue(ctx, entry, targets); err != nil {
+		return fmt.Errorf("failed to requeue dlq entry %s: %w", entry.ID, err)
+	}
+	log.Printf("Replayed DLQ entry %s (signal %s)", entry.ID, entry.Signal.SignalID)
+	return nil
+}
+
+// DeleteDLQ removes DLQ entries and returns how many existed
+func DeleteDLQ(ctx context.Context, ids ...string) (int64, error) {
+	if signalQueue == nil {
+		return 0, ErrQueueUnavailable
+	}
+	return signalQueue.DeleteDLQ(ctx, ids...)
+}
+
+// PurgeDLQ removes every DLQ entry
+func PurgeDLQ(ctx context.Context) (int64, error) {
+	if signalQueue == nil {
+		return 0, ErrQueueUnavailable
+	}
+	return signalQueue.PurgeDLQ(ctx)
+}
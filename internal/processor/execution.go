@@ -0,0 +1,68 @@
+package processor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Execution states of one signal on one target account
+const (
+	ExecutionPending   = "pending"
+	ExecutionSucceeded = "succeeded"
+	ExecutionFailed    = "failed"
+	ExecutionSkipped   = "skipped"
+)
+
+// executionTTL outlives the idempotency keys so DLQ replays days later still
+// know which targets are done
+const executionTTL = 7 * 24 * time.Hour
+
+// TargetExecution is the state of a signal on one target account
+type TargetExecution struct {
+	Target    string `json:"target"`
+	Status    string `json:"status"`
+	Error     string `json:"error,omitempty"`
+	OrderID   string `json:"order_id,omitempty"`
+	Attempts  int    `json:"attempts"`
+	UpdatedAt int64  `json:"updated_at"` // milliseconds
+}
+
+// executionKey identifies a signal across retries and DLQ replays
+func executionKey(source, signalID string) string {
+	return fmt.Sprintf("execution:%s:%s", strings.ToLower(source), signalID)
+}
+
+func beginExecution(ctx context.Context, key, target string) (int, error) {
+	return signalState.BeginExecution(ctx, key, target)
+}
+
+func finishExecution(ctx context.Context, key string, execution TargetExecution) error {
+	execution.UpdatedAt = time.Now().UnixMilli()
+	return signalState.FinishExecution(ctx, key, execution)
+}
+
+// GetExecutions returns the per-target state of a signal, sorted by target
+func GetExecutions(ctx context.Context, source, signalID string) ([]TargetExecution, error) {
+	if signalState == nil {
+		return nil, ErrQueueUnavailable
+	}
+	return signalState.Executions(ctx, executionKey(source, signalID))
+}
+
+// decodeExecutions parses stored states by target, sorted by target
+func decodeExecutions(raw map[string]string) []TargetExecution {
+	states := make([]TargetExecution, 0, len(raw))
+	for _, data := range raw {
+		var execution TargetExecution
+		if err := json.Unmarshal([]byte(data), &execution); err != nil {
+			continue
+		}
+		states = append(states, execution)
+	}
+	sort.Slice(states, func(i, j int) bool { return states[i].Target < states[j].Target })
+	return states
+}
@@ -0,0 +1,131 @@
+package processor
+
+import (
+	"context"
+	"crypto-sync-bot/internal/database"
+	"crypto-sync-bot/internal/models"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+// useSQLite points the database and the signal queue at a fresh SQLite file
+// for the duration of a test
+func useSQLite(t *testing.T) {
+	t.Helper()
+	if err := database.InitSQLite(filepath.Join(t.TempDir(), "test.db")); err != nil {
+		t.Fatalf("InitSQLite: %v", err)
+	}
+	prevQueue, prevState := signalQueue, signalState
+	signalQueue, signalState = newSQLiteQueue(), sqliteState{}
+	t.Cleanup(func() {
+		database.DB.Close()
+		database.DB = nil
+		signalQueue, signalState = prevQueue, prevState
+	})
+}
+
+// fakeExecutor is an in-memory exchange. Orders are kept by ID and every
+// call that changes one is recorded.
+type fakeExecutor struct {
+	mu        sync.Mutex
+	name      string
+	orders    map[string]*models.OrderResult
+	balance   float64
+	position  *models.Position
+	placed    []models.TradingSignal
+	amended   map[string]models.TradingSignal // order ID -> amendment
+	cancelled []string
+	// replace makes AmendOrder cancel and replace orders under a new ID
+	replace bool
+	// conditional reports whether stop-loss and take-profit are attached
+	conditional bool
+	nextID      int
+}
+
+func newFakeExecutor(name string) *fakeExecutor {
+	return &fakeExecutor{
+		name:    name,
+		orders:  make(map[string]*models.OrderResult),
+		amended: make(map[string]models.TradingSignal),
+	}
+}
+
+func (f *fakeExecutor) Name() string { return f.name }
+
+// addOrder stores a resting order as the exchange would report it
+func (f *fakeExecutor) addOrder(orderID, status string, filled float64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.orders[orderID] = &models.OrderResult{Exchange: f.name, OrderID: orderID, Status: status, FilledQuantity: filled}
+}
+
+func (f *fakeExecutor) PlaceOrder(ctx context.Context, signal *models.TradingSignal) (*models.OrderResult, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.placed = append(f.placed, *signal)
+	f.nextID++
+	order := &models.OrderResult{Exchange: f.name, Symbol: signal.Symbol, OrderID: fmt.Sprintf("placed-%d", f.nextID), Status: "NEW"}
+	f.orders[order.OrderID] = order
+	res := *order
+	return &res, nil
+}
+
+func (f *fakeExecutor) GetOrder(ctx context.Context, orderID, symbol string) (*models.OrderResult, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	order, ok := f.orders[orderID]
+	if !ok {
+		return nil, models.ErrOrderNotFound
+	}
+	res := *order
+	return &res, nil
+}
+
+func (f *fakeExecutor) CancelOrder(ctx context.Context, orderID, symbol string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	order, ok := f.orders[orderID]
+	if !ok {
+		return models.ErrOrderNotFound
+	}
+	order.Status = "CANCELLED"
+	f.cancelled = append(f.cancelled, orderID)
+	return nil
+}
+
+func (f *fakeExecutor) AmendOrder(ctx context.Context, orderID string, signal *models.TradingSignal) (*models.OrderResult, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	order, ok := f.orders[orderID]
+	if !ok {
+		return nil, models.ErrOrderNotFound
+	}
+	f.amended[orderID] = *signal
+	if !f.replace {
+		res := *order
+		return &res, nil
+	}
+	order.Status = "CANCELLED"
+	f.nextID++
+	replacement := &models.OrderResult{Exchange: f.name, Symbol: signal.Symbol, OrderID: fmt.Sprintf("replaced-%d", f.nextID), Status: "NEW"}
+	f.orders[replacement.OrderID] = replacement
+	res := *replacement
+	return &res, nil
+}
+
+func (f *fakeExecutor) GetBalance(ctx context.Context, asset string) (float64, error) {
+	return f.balance, nil
+}
+
+func (f *fakeExecutor) GetPosition(ctx context.Context, symbol string) (*models.Position, error) {
+	if f.position == nil {
+		return &models.Position{Symbol: symbol}, nil
+	}
+	return f.position, nil
+}
+
+func (f *fakeExecutor) SupportsConditionalOrders() bool { return f.conditional }
+
+func (f *fakeExecutor) Close() {}
@@ -2,24 +2,107 @@ package processor
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
-	"crypto-sync-bot/internal/database"
+	"crypto-sync-bot/internal/models"
 )
 
-func IsDuplicate(ctx context.Context, signalID, exchange string, quantity, price float64) (bool, error) {
-	key := fmt.Sprintf("signal:%s:%s:%.8f:%.8f", exchange, signalID, quantity, price)
-	
-	n, err := database.RDB.Exists(ctx, key).Result()
+// Reservation states. A reservation is taken before an order is placed and
+// completed once the exchange accepted it; one left in flight means the
+// outcome of the placement is unknown.
+const (
+	ReservationInFlight = "in_flight"
+	ReservationDone     = "done"
+)
+
+const (
+	reservationTTL = 24 * time.Hour
+	// inFlightGrace is how long an in-flight reservation is assumed to belong
+	// to a placement that is still running, before it is checked on the exchange
+	inFlightGrace = 30 * time.Second
+)
+
+// Reservation is the idempotency record of one signal on one target
+type Reservation struct {
+	Status        string
+	ClientOrderID string
+	ReservedAt    int64 // milliseconds
+}
+
+// reservationKey identifies a signal on one target. It deliberately leaves out
+// quantity and price, which may differ by rounding between deliveries.
+func reservationKey(target, source, signalKey string) string {
+	return fmt.Sprintf("idempotency:%s:%s:%s", target, strings.ToLower(source), signalKey)
+}
+
+// clientOrderID derives the client order ID a signal is placed with on target.
+// It is the same on every attempt, so exchanges reject or reveal a repeat.
+// 32 alphanumerics starting with a letter fit every supported exchange.
+func clientOrderID(target, source, signalKey string) string {
+	sum := sha256.Sum256([]byte(target + "|" + strings.ToLower(source) + "|" + signalKey))
+	return "csb" + hex.EncodeToString(sum[:])[:29]
+}
+
+// Reserve atomically claims key for a placement with clientOrderID. If the key
+// is already reserved it returns the existing reservation and false.
+func Reserve(ctx context.Context, key, clientOrderID string) (*Reservation, bool, error) {
+	return signalState.Reserve(ctx, key, clientOrderID)
+}
+
+// CompleteReservation records that the order for key was accepted
+func CompleteReservation(ctx context.Context, key string) error {
+	return signalState.CompleteReservation(ctx, key)
+}
+
+// ReleaseReservation drops a reservation whose order was certainly not placed
+func ReleaseReservation(ctx context.Context, key string) error {
+	return signalState.ReleaseReservation(ctx, key)
+}
+
+// recoverInFlight settles a reservation left in flight by an earlier attempt
+// that crashed or failed without a clear answer. If the exchange has the order
+// it is returned and the reservation completed. If it does not, or the
+// executor cannot look orders up by client order ID, the reservation is taken
+// over and (nil, nil) tells the caller to place it again.
+func recoverInFlight(ctx context.Context, key string, res *Reservation, executor models.ExchangeExecutor, symbol string) (*models.OrderResult, error) {
+	if time.Since(time.UnixMilli(res.ReservedAt)) < inFlightGrace {
+		return nil, fmt.Errorf("order %s is still being placed", res.ClientOrderID)
+	}
+	// Without a lookup the order is placed again under the same client order
+	// ID, which the exchange rejects if the earlier attempt went through
+	if lookup, ok := clientIDLookup(executor); ok {
+		order, err := lookup.GetOrderByClientID(ctx, res.ClientOrderID, symbol)
+		if err == nil {
+			return order, CompleteReservation(ctx, key)
+		}
+		if !errors.Is(err, models.ErrOrderNotFound) {
+			return nil, fmt.Errorf("failed to look up in-flight order %s: %w", res.ClientOrderID, err)
+		}
+	}
+
+	took, err := signalState.TakeOver(ctx, key, res.ReservedAt)
 	if err != nil {
-		return false, err
+		return nil, err
+	}
+	if !took {
+		return nil, fmt.Errorf("in-flight order %s was taken over by another consumer", res.ClientOrderID)
 	}
-	
-	return n > 0, nil
+	return nil, nil
 }
 
-func MarkProcessed(ctx context.Context, signalID, exchange string, quantity, price float64) error {
-	key := fmt.Sprintf("signal:%s:%s:%.8f:%.8f", exchange, signalID, quantity, price)
-	return database.RDB.Set(ctx, key, "1", 24*time.Hour).Err()
+// clientIDLookup returns executor as an OrderLookup if it can find orders by client order ID
+func clientIDLookup(executor models.ExchangeExecutor) (models.OrderLookup, bool) {
+	lookup, ok := executor.(models.OrderLookup)
+	if !ok {
+		return nil, false
+	}
+	if support, ok := executor.(models.ClientIDLookupSupport); ok && !support.SupportsClientIDLookup() {
+		return nil, false
+	}
+	return lookup, true
 }
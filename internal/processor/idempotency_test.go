@@ -0,0 +1,42 @@
+package processor
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestClientOrderID(t *testing.T) {
+	// 32 alphanumerics starting with a letter fit every supported exchange
+	format := regexp.MustCompile(`^[a-z][a-z0-9]{31}$`)
+
+	tests := []struct {
+		name                      string
+		target, source, signalKey string
+		other                     [3]string // inputs of an ID that must differ, or equal if same
+		same                      bool
+	}{
+		{name: "stable", target: "bybit", source: "binance", signalKey: "123", other: [3]string{"bybit", "binance", "123"}, same: true},
+		{name: "source case does not matter", target: "bybit", source: "Binance", signalKey: "123", other: [3]string{"bybit", "binance", "123"}, same: true},
+		{name: "per target", target: "bybit", source: "binance", signalKey: "123", other: [3]string{"okx", "binance", "123"}},
+		{name: "per source", target: "bybit", source: "binance", signalKey: "123", other: [3]string{"bybit", "binance-2", "123"}},
+		{name: "per signal", target: "bybit", source: "binance", signalKey: "123", other: [3]string{"bybit", "binance", "124"}},
+		{name: "long signal key", target: "bybit", source: "binance", signalKey: "amend-1234567890123456789:42:7", other: [3]string{"bybit", "binance", "amend-1234567890123456789:42:8"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id := clientOrderID(tt.target, tt.source, tt.signalKey)
+			if !format.MatchString(id) {
+				t.Errorf("clientOrderID = %q, want 32 lowercase alphanumerics starting with a letter", id)
+			}
+			if again := clientOrderID(tt.target, tt.source, tt.signalKey); again != id {
+				t.Errorf("clientOrderID changed between calls: %q, %q", id, again)
+			}
+			other := clientOrderID(tt.other[0], tt.other[1], tt.other[2])
+			if (other == id) != tt.same {
+				t.Errorf("clientOrderID(%q) = %q and clientOrderID(%q) = %q, want same=%v",
+					[]string{tt.target, tt.source, tt.signalKey}, id, tt.other, other, tt.same)
+			}
+		})
+	}
+}
@@ -0,0 +1,205 @@
+package processor
+
+import (
+	"context"
+	"crypto-sync-bot/internal/config"
+	"crypto-sync-bot/internal/database"
+	"crypto-sync-bot/internal/models"
+	"fmt"
+	"log"
+	"strconv"
+)
+
+// linkOrder records a target order placed from a source order, if it may rest
+// in the book. Market orders complete at once and are never linked.
+func linkOrder(target string, signal *models.TradingSignal, orderID string) {
+	if signal.SourceOrderID == "" || signal.OrderType != "LIMIT" || orderID == "" {
+		return
+	}
+	link := &database.OrderLink{
+		Source:        signal.Source,
+		SourceOrderID: signal.SourceOrderID,
+		Target:        target,
+		Symbol:        signal.Symbol,
+		OrderID:       orderID,
+		Status:        database.OrderLinkOpen,
+	}
+	if err := database.SaveOrderLink(link); err != nil {
+		log.Printf("Failed to link %s order %s to source order %s: %v", target, orderID, signal.SourceOrderID, err)
+	}
+}
+
+// applyOrderChange cancels or amends the copies of the source order on target.
+// Copies found to be closed already are unlinked.
+func (p *SignalProcessor) applyOrderChange(ctx context.Context, item config.SyncItem, target string, executor models.ExchangeExecutor, signal *models.TradingSignal, signalKey string) error {
+	switch signal.Action {
+	case models.ActionCancel:
+		links, err := database.OpenOrderLinks(signal.Source, signal.SourceOrderID, target)
+		if err != nil {
+			return fmt.Errorf("failed to load order links: %w", err)
+		}
+		if len(links) == 0 {
+			log.Printf("%s has no open copy of source order %s, nothing to %s", executor.Name(), signal.SourceOrderID, signal.Action)
+			return nil
+		}
+		for i := range links {
+			if err := cancelLinked(ctx, executor, &links[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	case models.ActionAmend:
+		return p.amendLinks(ctx, item, target, executor, signal, signalKey)
+	default:
+		return fmt.Errorf("unknown signal action %q", signal.Action)
+	}
+}
+
+func cancelLinked(ctx context.Context, executor models.ExchangeExecutor, link *database.OrderLink) error {
+	if err := executor.CancelOrder(ctx, link.OrderID, link.Symbol); err != nil && !orderClosed(ctx, executor, link) {
+		return fmt.Errorf("failed to cancel order %s: %w", link.OrderID, err)
+	}
+	log.Printf("%s cancelled order %s mirroring source order %s", executor.Name(), link.OrderID, link.SourceOrderID)
+	return closeLink(link)
+}
+
+// amendLinks moves the copies of an amended source order to its new terms.
+// The amended quantity, scaled for target, covers all copies together: what
+// they have filled counts against it, and the rest rests on the newest open
+// copy alone. Other open copies are cancelled.
+func (p *SignalProcessor) amendLinks(ctx context.Context, item config.SyncItem, target string, executor models.ExchangeExecutor, signal *models.TradingSignal, signalKey string) error {
+	links, err := database.OrderLinks(signal.Source, signal.SourceOrderID, target)
+	if err != nil {
+		return fmt.Errorf("failed to load order links: %w", err)
+	}
+
+	var filled float64
+	var resting []*database.OrderLink
+	restingFilled := make(map[int64]float64)
+	for i := range links {
+		link := &links[i]
+		order, err := executor.GetOrder(ctx, link.OrderID, link.Symbol)
+		if err != nil {
+			if link.Status != database.OrderLinkOpen {
+				// Closed copies only add their fills; one that cannot be read is left out
+				log.Printf("%s could not read closed order %s: %v", executor.Name(), link.OrderID, err)
+				continue
+			}
+			return fmt.Errorf("failed to check order %s: %w", link.OrderID, err)
+		}
+		filled += order.FilledQuantity
+		if link.Status != database.OrderLinkOpen {
+			continue
+		}
+		if isClosedStatus(order.Status) {
+			if err := closeLink(link); err != nil {
+				return err
+			}
+			continue
+		}
+		resting = append(resting, link)
+		restingFilled[link.ID] = order.FilledQuantity
+	}
+	if len(resting) == 0 {
+		log.Printf("%s has no open copy of source order %s, nothing to %s", executor.Name(), signal.SourceOrderID, signal.Action)
+		return nil
+	}
+
+	keep := resting[len(resting)-1]
+	for _, link := range resting[:len(resting)-1] {
+		if err := cancelLinked(ctx, executor, link); err != nil {
+			return err
+		}
+	}
+
+	total, err := p.sizeOrder(ctx, item, target, executor, signal)
+	if err != nil {
+		return err
+	}
+	remaining := total - filled
+	if remaining <= 0 {
+		log.Printf("%s copies of source order %s already filled %.8f of %.8f", executor.Name(), signal.SourceOrderID, filled, total)
+		return cancelLinked(ctx, executor, keep)
+	}
+
+	// The copy is protected like a new order at the amended price and quantity,
+	// which replacements and Lighter's separate legs pick up
+	protected := *signal
+	protected.Quantity = restingFilled[keep.ID] + remaining
+	if err := p.protectOrder(ctx, executor, &protected); err != nil {
+		return err
+	}
+
+	// Replacements need a client order ID of their own per copy
+	clientID := clientOrderID(target, signal.Source, signalKey+":"+strconv.FormatInt(keep.ID, 10))
+	return amendLinked(ctx, target, executor, &protected, keep, protected.Quantity, clientID)
+}
+
+// amendLinked moves one copy to the signal's price and the given total quantity
+func amendLinked(ctx context.Context, target string, executor models.ExchangeExecutor, signal *models.TradingSignal, link *database.OrderLink, quantity float64, clientID string) error {
+	amended := *signal
+	amended.ClientOrderID = clientID
+	amended.Quantity = quantity
+
+	res, err := executor.AmendOrder(ctx, link.OrderID, &amended)
+	if err != nil {
+		if orderClosed(ctx, executor, link) {
+			log.Printf("%s order %s is no longer open, not amending", executor.Name(), link.OrderID)
+			return closeLink(link)
+		}
+		return fmt.Errorf("failed to amend order %s: %w", link.OrderID, err)
+	}
+
+	switch {
+	case res.Status == "skipped":
+		// The new size is below the exchange minimum; the copy keeps its old terms
+		log.Printf("%s amendment of order %s skipped: %s", executor.Name(), link.OrderID, res.ErrorMessage)
+		return nil
+	case res.Status == "CANCELLED":
+		// Replaced by nothing: the copy had already filled up to the new quantity
+		return closeLink(link)
+	case res.OrderID != "" && res.OrderID != link.OrderID:
+		// Cancelled and replaced. The replacement is linked as a new copy, and
+		// the cancelled one stays linked, closed, so its fills keep counting.
+		res.Exchange = target
+		database.SaveOrderResult(res)
+		if err := closeLink(link); err != nil {
+			return err
+		}
+		log.Printf("%s replaced order %s with %s at %.8f @ %.8f for source order %s", executor.Name(), link.OrderID, res.OrderID, amended.Quantity, amended.Price, link.SourceOrderID)
+		return database.SaveOrderLink(&database.OrderLink{
+			Source:        link.Source,
+			SourceOrderID: link.SourceOrderID,
+			Target:        link.Target,
+			Symbol:        link.Symbol,
+			OrderID:       res.OrderID,
+			Status:        database.OrderLinkOpen,
+		})
+	}
+	log.Printf("%s amended order %s to %.8f @ %.8f for source order %s", executor.Name(), link.OrderID, amended.Quantity, amended.Price, link.SourceOrderID)
+	return database.UpdateOrderLink(link)
+}
+
+// orderClosed reports whether a linked order is known to be no longer resting,
+// which explains a failed cancel or amendment
+func orderClosed(ctx context.Context, executor models.ExchangeExecutor, link *database.OrderLink) bool {
+	order, err := executor.GetOrder(ctx, link.OrderID, link.Symbol)
+	if err != nil {
+		return false
+	}
+	return isClosedStatus(order.Status)
+}
+
+// isClosedStatus reports whether a normalized order status is final
+func isClosedStatus(status string) bool {
+	switch status {
+	case "FILLED", "CANCELLED", "REJECTED":
+		return true
+	}
+	return false
+}
+
+func closeLink(link *database.OrderLink) error {
+	link.Status = database.OrderLinkClosed
+	return database.UpdateOrderLink(link)
+}
@@ -0,0 +1,141 @@
+package processor
+
+import (
+	"context"
+	"crypto-sync-bot/internal/config"
+	"crypto-sync-bot/internal/database"
+	"crypto-sync-bot/internal/models"
+	"math"
+	"testing"
+)
+
+// linkCopy links a resting target order to source order 100, like linkOrder
+func linkCopy(t *testing.T, exec *fakeExecutor, orderID, status string, filled float64) {
+	t.Helper()
+	exec.addOrder(orderID, status, filled)
+	err := database.SaveOrderLink(&database.OrderLink{
+		Source:        "lead",
+		SourceOrderID: "100",
+		Target:        exec.name,
+		Symbol:        "BTC-USDT",
+		OrderID:       orderID,
+		Status:        database.OrderLinkOpen,
+	})
+	if err != nil {
+		t.Fatalf("SaveOrderLink: %v", err)
+	}
+}
+
+func TestAmendLinksSplitsQuantityAcrossCopies(t *testing.T) {
+	tests := []struct {
+		name    string
+		replace bool
+		// filled quantities of the two copies, oldest first
+		filled [2]float64
+		// amended source quantity; the item copies it at ratio 0.5
+		quantity float64
+
+		wantCancelled []string
+		wantAmended   float64 // total quantity the newest copy is amended to, 0 for none
+		wantOpen      []string
+	}{
+		{
+			name:          "newest copy takes the rest",
+			filled:        [2]float64{0.3, 0.1},
+			quantity:      4,
+			wantCancelled: []string{"o1"},
+			wantAmended:   0.1 + (2 - 0.3 - 0.1),
+			wantOpen:      []string{"o2"},
+		},
+		{
+			name:          "replacement is linked as a new copy",
+			replace:       true,
+			filled:        [2]float64{0.3, 0.1},
+			quantity:      4,
+			wantCancelled: []string{"o1"},
+			wantAmended:   0.1 + (2 - 0.3 - 0.1),
+			wantOpen:      []string{"replaced-1"},
+		},
+		{
+			name:          "copies already filled the amended quantity",
+			filled:        [2]float64{0.6, 0.5},
+			quantity:      2,
+			wantCancelled: []string{"o1", "o2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useSQLite(t)
+			exec := newFakeExecutor("target")
+			exec.replace = tt.replace
+			exec.conditional = true
+			linkCopy(t, exec, "o1", "PARTIALLY_FILLED", tt.filled[0])
+			linkCopy(t, exec, "o2", "PARTIALLY_FILLED", tt.filled[1])
+
+			cfg := &config.Config{Sync: config.SyncConfig{StopLossRatio: 0.05}}
+			p := NewSignalProcessor(cfg, map[string]models.ExchangeExecutor{"target": exec})
+			item := config.SyncItem{Source: "lead", Sizing: config.SizingConfig{Mode: config.SizingRatio, Value: 0.5}}
+			signal := &models.TradingSignal{
+				Symbol:        "BTC-USDT",
+				Side:          "BUY",
+				OrderType:     "LIMIT",
+				Quantity:      tt.quantity,
+				Price:         101,
+				Source:        "lead",
+				Action:        models.ActionAmend,
+				SourceOrderID: "100",
+			}
+			if err := p.applyOrderChange(context.Background(), item, "target", exec, signal, "amend-1"); err != nil {
+				t.Fatalf("applyOrderChange: %v", err)
+			}
+
+			if !equalStrings(exec.cancelled, tt.wantCancelled) {
+				t.Errorf("cancelled %v, want %v", exec.cancelled, tt.wantCancelled)
+			}
+			if tt.wantAmended == 0 {
+				if len(exec.amended) != 0 {
+					t.Errorf("amended %v, want none", exec.amended)
+				}
+			} else {
+				amended, ok := exec.amended["o2"]
+				if len(exec.amended) != 1 || !ok {
+					t.Fatalf("amended %v, want only o2", exec.amended)
+				}
+				if math.Abs(amended.Quantity-tt.wantAmended) > 1e-9 {
+					t.Errorf("o2 amended to %v, want %v", amended.Quantity, tt.wantAmended)
+				}
+				if amended.Price != signal.Price {
+					t.Errorf("o2 amended to price %v, want %v", amended.Price, signal.Price)
+				}
+				if want := signal.Price * 0.95; math.Abs(amended.StopLossPrice-want) > 1e-9 {
+					t.Errorf("o2 amended with stop-loss %v, want %v", amended.StopLossPrice, want)
+				}
+			}
+
+			links, err := database.OpenOrderLinks("lead", "100", "target")
+			if err != nil {
+				t.Fatalf("OpenOrderLinks: %v", err)
+			}
+			var open []string
+			for _, link := range links {
+				open = append(open, link.OrderID)
+			}
+			if !equalStrings(open, tt.wantOpen) {
+				t.Errorf("open copies %v, want %v", open, tt.wantOpen)
+			}
+		})
+	}
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -1,20 +1,121 @@
 package processor
 
 import (
+	"context"
+	"crypto-sync-bot/internal/database"
 	"crypto-sync-bot/internal/models"
-	"fmt"
+	"log"
 	"time"
 )
 
-// SaveToOutbox saves a message to the outbox table (mock implementation)
-func SaveToOutbox(payload string) error {
+const (
+	outboxPollInterval = time.Second
+	outboxBatch        = 100
+	// outboxPublishLease gives the producer that wrote a row the first chance to
+	// publish it before the relay does
+	outboxPublishLease = 5 * time.Second
+	outboxMaxAttempts  = 20
+	outboxMaxBackoff   = time.Minute
+	outboxRetention    = 7 * 24 * time.Hour
+)
+
+// SaveToOutbox durably stores a signal payload for publishing
+func SaveToOutbox(payload string) (*models.Outbox, error) {
+	now := time.Now()
 	outbox := &models.Outbox{
-		ID:        fmt.Sprintf("%d", time.Now().UnixNano()),
-		Payload:   payload,
-		Status:    "pending",
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
-	}
-	fmt.Printf("Mock: Saving to outbox: %+v\n", outbox)
-	return nil
+		Payload:       payload,
+		Status:        database.OutboxPending,
+		NextAttemptAt: now.Add(outboxPublishLease),
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+	if err := database.InsertOutbox(outbox); err != nil {
+		return nil, err
+	}
+	return outbox, nil
+}
+
+// publishOutbox adds an outbox row to the signal queue and records the outcome.
+// A publish that fails is retried by the relay with exponential backoff until
+// outboxMaxAttempts, after which the row is marked failed.
+func publishOutbox(ctx context.Context, outbox *models.Outbox) error {
+	outbox.Attempts++
+	err := publish(ctx, outbox.Payload)
+	if err == nil {
+		outbox.Status, outbox.LastError = database.OutboxProcessed, ""
+	} else {
+		outbox.LastError = err.Error()
+		if outbox.Attempts >= outboxMaxAttempts {
+			outbox.Status = database.OutboxFailed
+			log.Printf("Outbox signal %d failed %d times, giving up: %v", outbox.ID, outbox.Attempts, err)
+		} else {
+			backoff := time.Second << min(outbox.Attempts-1, 6)
+			outbox.NextAttemptAt = time.Now().Add(min(backoff, outboxMaxBackoff))
+		}
+	}
+	if updateErr := database.UpdateOutbox(outbox); updateErr != nil {
+		// Left pending: the relay publishes it again and idempotency drops the repeat
+		log.Printf("Failed to update outbox signal %d: %v", outbox.ID, updateErr)
+	}
+	return err
+}
+
+func publish(ctx context.Context, payload string) error {
+	if signalQueue == nil {
+		return ErrQueueUnavailable
+	}
+	return signalQueue.Publish(ctx, payload, nil)
+}
+
+// RunOutboxRelay publishes outbox rows that were not published when they were
+// written, e.g. because Redis was briefly unreachable, until ctx is cancelled.
+func RunOutboxRelay(ctx context.Context) {
+	if database.MySQLDB == nil && database.DB == nil {
+		log.Println("Outbox relay skipped: no database available")
+		return
+	}
+
+	ticker := time.NewTicker(outboxPollInterval)
+	defer ticker.Stop()
+	lastPrune := time.Time{}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if signalQueue != nil {
+			relayOutbox(ctx)
+		}
+		if time.Since(lastPrune) > time.Hour {
+			lastPrune = time.Now()
+			if n, err := database.PruneOutbox(time.Now().Add(-outboxRetention)); err != nil {
+				log.Printf("Failed to prune outbox: %v", err)
+			} else if n > 0 {
+				log.Printf("Pruned %d processed outbox signal(s)", n)
+			}
+		}
+	}
+}
+
+func relayOutbox(ctx context.Context) {
+	due, err := database.DueOutbox(time.Now(), outboxBatch)
+	if err != nil {
+		log.Printf("Failed to read outbox: %v", err)
+		return
+	}
+	for i := range due {
+		outbox := &due[i]
+		claimed, err := database.ClaimOutbox(outbox, time.Now().Add(outboxPublishLease))
+		if err != nil || !claimed {
+			continue
+		}
+		if err := publishOutbox(ctx, outbox); err != nil {
+			log.Printf("Outbox signal %d publish failed (attempt %d): %v", outbox.ID, outbox.Attempts, err)
+			// The queue is most likely down; leave the rest for the next poll
+			return
+		}
+		log.Printf("Relayed outbox signal %d", outbox.ID)
+	}
 }
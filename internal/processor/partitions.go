@@ -0,0 +1,191 @@
+package processor
+
+import (
+	"context"
+	"crypto-sync-bot/internal/database"
+	"crypto-sync-bot/internal/models"
+	"fmt"
+	"hash/fnv"
+	"log"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Signals are spread over partitioned streams by symbol. Each partition is
+// leased to exactly one processor replica at a time, so all signals for a
+// symbol are consumed by a single instance, in stream order.
+const (
+	membersKey         = "signals:consumers" // sorted set of live consumers by heartbeat
+	leaseTTL           = 15 * time.Second
+	leaseRenewInterval = 5 * time.Second
+)
+
+var (
+	renewLease = redis.NewScript(`if redis.call("GET", KEYS[1]) == ARGV[1] then return redis.call("PEXPIRE", KEYS[1], ARGV[2]) end return 0`)
+	dropLease  = redis.NewScript(`if redis.call("GET", KEYS[1]) == ARGV[1] then return redis.call("DEL", KEYS[1]) end return 0`)
+)
+
+// ensureGroups creates the consumer group on every partition stream
+func (q *redisQueue) ensureGroups(ctx context.Context) {
+	for i := 0; i < q.partitions; i++ {
+		err := database.RDB.XGroupCreateMkStream(ctx, partitionStream(i), consumerGroup, "$").Err()
+		if err != nil {
+			log.Printf("Note: Consumer group setup for %s: %v (usually means it already exists)", partitionStream(i), err)
+		}
+	}
+}
+
+// partitionStream names partition i. The first partition keeps the original
+// stream name so signals queued before partitioning was enabled are still consumed.
+func partitionStream(i int) string {
+	if i == 0 {
+		return signalStream
+	}
+	return fmt.Sprintf("%s:%d", signalStream, i)
+}
+
+func leaseKey(i int) string {
+	return fmt.Sprintf("signals:lease:%d", i)
+}
+
+func symbolHash(symbol string) uint32 {
+	if parsed, err := models.ParseSymbol(symbol); err == nil {
+		symbol = parsed.String()
+	}
+	h := fnv.New32a()
+	h.Write([]byte(symbol))
+	return h.Sum32()
+}
+
+// streamForSymbol returns the partition stream that carries signals for symbol
+func (q *redisQueue) streamForSymbol(symbol string) string {
+	return partitionStream(int(symbolHash(symbol) % uint32(q.partitions)))
+}
+
+// OwnsSymbol reports whether this instance holds the lease on symbol's partition
+func (q *redisQueue) OwnsSymbol(symbol string) bool {
+	q.ownedMu.Lock()
+	defer q.ownedMu.Unlock()
+	return q.owned[int(symbolHash(symbol)%uint32(q.partitions))]
+}
+
+// ownedStreams returns the partition streams this instance currently holds
+func (q *redisQueue) ownedStreams() []string {
+	q.ownedMu.Lock()
+	defer q.ownedMu.Unlock()
+
+	ids := make([]int, 0, len(q.owned))
+	for i := range q.owned {
+		ids = append(ids, i)
+	}
+	sort.Ints(ids)
+	streams := make([]string, len(ids))
+	for n, i := range ids {
+		streams[n] = partitionStream(i)
+	}
+	return streams
+}
+
+// runLeases keeps this instance registered as a live consumer and extends the
+// leases it holds until the queue is closed
+func (q *redisQueue) runLeases() {
+	ctx := context.Background()
+	q.heartbeat(ctx)
+
+	ticker := time.NewTicker(leaseRenewInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-q.stop:
+			return
+		case <-ticker.C:
+			q.heartbeat(ctx)
+		}
+	}
+}
+
+func (q *redisQueue) heartbeat(ctx context.Context) {
+	now := time.Now()
+	database.RDB.ZAdd(ctx, membersKey, redis.Z{Score: float64(now.UnixMilli()), Member: q.consumer})
+	database.RDB.ZRemRangeByScore(ctx, membersKey, "-inf", strconv.FormatInt(now.Add(-leaseTTL).UnixMilli(), 10))
+
+	q.ownedMu.Lock()
+	defer q.ownedMu.Unlock()
+	for i := range q.owned {
+		renewed, err := renewLease.Run(ctx, database.RDB, []string{leaseKey(i)}, q.consumer, leaseTTL.Milliseconds()).Int()
+		if err != nil {
+			log.Printf("Failed to renew lease on %s: %v", partitionStream(i), err)
+			continue
+		}
+		if renewed == 0 {
+			log.Printf("Lost lease on %s", partitionStream(i))
+			delete(q.owned, i)
+		}
+	}
+}
+
+// rebalance moves this instance towards an even share of the partitions among
+// live consumers, releasing extras and leasing free ones. It runs between
+// batches so a partition is never released while its signals are in flight.
+// Newly leased partitions are returned so their abandoned signals can be recovered.
+func (q *redisQueue) rebalance(ctx context.Context) []string {
+	if time.Since(q.lastRebalance) < leaseRenewInterval {
+		return nil
+	}
+	q.lastRebalance = time.Now()
+
+	live, err := database.RDB.ZCount(ctx, membersKey, strconv.FormatInt(time.Now().Add(-leaseTTL).UnixMilli(), 10), "+inf").Result()
+	if err != nil {
+		log.Printf("Failed to count consumers: %v", err)
+		return nil
+	}
+	if live < 1 {
+		live = 1
+	}
+	share := (q.partitions + int(live) - 1) / int(live)
+
+	q.ownedMu.Lock()
+	defer q.ownedMu.Unlock()
+
+	for i := q.partitions - 1; i >= 0 && len(q.owned) > share; i-- {
+		if !q.owned[i] {
+			continue
+		}
+		dropLease.Run(ctx, database.RDB, []string{leaseKey(i)}, q.consumer)
+		delete(q.owned, i)
+		log.Printf("Released %s to rebalance (%d consumer(s))", partitionStream(i), live)
+	}
+
+	var acquired []string
+	for i := 0; i < q.partitions && len(q.owned) < share; i++ {
+		if q.owned[i] {
+			continue
+		}
+		ok, err := database.RDB.SetNX(ctx, leaseKey(i), q.consumer, leaseTTL).Result()
+		if err != nil {
+			log.Printf("Failed to lease %s: %v", partitionStream(i), err)
+			continue
+		}
+		if ok {
+			q.owned[i] = true
+			acquired = append(acquired, partitionStream(i))
+			log.Printf("Consumer %s leased %s", q.consumer, partitionStream(i))
+		}
+	}
+	return acquired
+}
+
+// releaseLeases hands every held partition back so other replicas can take
+// over without waiting for the leases to expire
+func (q *redisQueue) releaseLeases(ctx context.Context) {
+	q.ownedMu.Lock()
+	defer q.ownedMu.Unlock()
+	for i := range q.owned {
+		dropLease.Run(ctx, database.RDB, []string{leaseKey(i)}, q.consumer)
+		delete(q.owned, i)
+	}
+	database.RDB.ZRem(ctx, membersKey, q.consumer)
+}
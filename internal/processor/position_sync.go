@@ -0,0 +1,169 @@
+package processor
+
+import (
+	"context"
+	"crypto-sync-bot/internal/config"
+	"crypto-sync-bot/internal/database"
+	"crypto-sync-bot/internal/metrics"
+	"crypto-sync-bot/internal/models"
+	"fmt"
+	"log"
+	"math"
+	"sync"
+	"time"
+)
+
+// positionEpsilon ignores float noise when comparing positions; real minimums
+// are enforced by the executors' instrument rounding
+const positionEpsilon = 1e-9
+
+// splitSyncItems separates position-mode items from signal-mode ones
+func splitSyncItems(items []config.SyncItem) (signalItems, positionItems []config.SyncItem) {
+	for _, item := range items {
+		if item.Mode == config.SyncModePosition {
+			positionItems = append(positionItems, item)
+		} else {
+			signalItems = append(signalItems, item)
+		}
+	}
+	return signalItems, positionItems
+}
+
+// runPositionSync reconciles every enabled position-mode sync item on a fixed
+// interval. Wildcard items have no symbol list to poll, so they are only
+// reconciled when a source event names the symbol. Each symbol is reconciled
+// by the replica leasing its partition, the one that also handles its source
+// events, so replicas do not each correct the same difference.
+func (p *SignalProcessor) runPositionSync() {
+	interval := time.Duration(p.config.GetSync().PositionSyncInterval) * time.Second
+	if interval <= 0 {
+		interval = 60 * time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.stopChan:
+			return
+		case <-ticker.C:
+			for _, item := range p.config.GetSyncItems() {
+				if !item.Enabled || item.Mode != config.SyncModePosition || item.AllSymbols() {
+					continue
+				}
+				if signalQueue != nil && !signalQueue.OwnsSymbol(item.Symbol) {
+					continue
+				}
+				p.syncItemPositions(item, item.Symbol)
+			}
+		}
+	}
+}
+
+// syncItemPositions brings every target of item in line with the scaled source
+// position in symbol. It returns the targets that could not be synced.
+func (p *SignalProcessor) syncItemPositions(item config.SyncItem, symbol string) map[string]error {
+	parsed, err := models.ParseSymbol(symbol)
+	if err != nil {
+		log.Printf("Position sync %s: invalid symbol %q: %v", item.ID, symbol, err)
+		return map[string]error{item.Source: err}
+	}
+	symbol = parsed.String()
+
+	ctx, cancel := context.WithTimeout(p.ctx, p.orderTimeout())
+	source, err := p.sourcePosition(ctx, item.Source, symbol)
+	cancel()
+	if err != nil {
+		log.Printf("Position sync %s: failed to get %s position on %s: %v", item.ID, symbol, item.Source, err)
+		return map[string]error{item.Source: err}
+	}
+
+	targets, missing := p.routeTargets([]config.SyncItem{item})
+	for _, id := range missing {
+		log.Printf("Position sync %s: target %s is not configured, skipping", item.ID, id)
+	}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	failures := make(map[string]error)
+	for id, target := range targets {
+		wg.Add(1)
+		go func(id string, target route) {
+			defer wg.Done()
+			if err := p.syncTargetPosition(item, id, target.executor, source); err != nil {
+				log.Printf("Position sync %s: %s %s failed: %v", item.ID, id, symbol, err)
+				mu.Lock()
+				failures[id] = err
+				mu.Unlock()
+			}
+		}(id, target)
+	}
+	wg.Wait()
+	return failures
+}
+
+// syncTargetPosition places the order that moves one target to its desired position.
+// Runs for the same target and symbol are serialized so a periodic pass and an
+// event-driven one cannot both correct the same difference.
+func (p *SignalProcessor) syncTargetPosition(item config.SyncItem, target string, executor models.ExchangeExecutor, source *models.Position) error {
+	lock, _ := p.positionLocks.LoadOrStore(target+":"+source.Symbol, &sync.Mutex{})
+	lock.(*sync.Mutex).Lock()
+	defer lock.(*sync.Mutex).Unlock()
+
+	ctx, cancel := context.WithTimeout(p.ctx, p.orderTimeout())
+	defer cancel()
+
+	ratio, err := p.positionRatio(ctx, item, target, executor, source.Symbol)
+	if err != nil {
+		return err
+	}
+	actual, err := executor.GetPosition(ctx, source.Symbol)
+	if err != nil {
+		return fmt.Errorf("failed to get position: %w", err)
+	}
+
+	desired := source.Size * ratio
+	diff := desired - actual.Size
+	if math.Abs(diff) <= positionEpsilon {
+		return nil
+	}
+
+	side := "BUY"
+	if diff < 0 {
+		side = "SELL"
+	}
+	now := time.Now()
+	signal := &models.TradingSignal{
+		SignalID:  fmt.Sprintf("position-%s-%d", item.ID, now.UnixNano()),
+		Symbol:    source.Symbol,
+		Side:      side,
+		OrderType: "MARKET",
+		Quantity:  math.Abs(diff),
+		Timestamp: now.UnixMilli(),
+		Source:    item.Source,
+	}
+	signal.ClientOrderID = clientOrderID(target, item.Source, signal.SignalID)
+	if err := p.riskManager.PreOrderCheck(signal); err != nil {
+		return err
+	}
+
+	log.Printf("Position sync %s: %s %s at %.8f, want %.8f (source %.8f x %.4f), placing %s %.8f",
+		item.ID, target, source.Symbol, actual.Size, desired, source.Size, ratio, side, signal.Quantity)
+
+	res, err := executor.PlaceOrder(ctx, signal)
+	if res != nil {
+		res.Exchange = target
+		database.SaveOrderResult(res)
+	}
+	switch {
+	case err != nil:
+		metrics.OrdersCounter.WithLabelValues(target, "failed").Inc()
+		return err
+	case res != nil && res.Status == "skipped":
+		// Difference is below the exchange minimum; nothing to correct
+		metrics.OrdersCounter.WithLabelValues(target, "skipped").Inc()
+	default:
+		metrics.OrdersCounter.WithLabelValues(target, "success").Inc()
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package processor
+
+import (
+	"context"
+	"crypto-sync-bot/internal/models"
+	"fmt"
+	"log"
+)
+
+// protectOrder sets the stop-loss of a mirrored order, if the signal carries
+// none, at StopLossRatio away from its price. Only orders that open or add to
+// the target position are protected: on an order that reduces it, the stop
+// would sit on the wrong side of what is left. Executors that cannot attach
+// conditional orders get the bare order, with a warning.
+func (p *SignalProcessor) protectOrder(ctx context.Context, executor models.ExchangeExecutor, signal *models.TradingSignal) error {
+	if ratio := p.config.GetSync().StopLossRatio; signal.StopLossPrice == 0 && ratio > 0 && signal.Price > 0 {
+		switch signal.Side {
+		case "BUY":
+			signal.StopLossPrice = signal.Price * (1 - ratio)
+		case "SELL":
+			signal.StopLossPrice = signal.Price * (1 + ratio)
+		}
+	}
+	if signal.StopLossPrice == 0 && signal.TakeProfitPrice == 0 {
+		return nil
+	}
+
+	if conditional, ok := executor.(models.ConditionalOrderSupport); !ok || !conditional.SupportsConditionalOrders() {
+		log.Printf("%s does not support conditional orders, placing %s %s without stop-loss/take-profit", executor.Name(), signal.Side, signal.Symbol)
+		signal.StopLossPrice, signal.TakeProfitPrice = 0, 0
+		return nil
+	}
+
+	position, err := executor.GetPosition(ctx, signal.Symbol)
+	if err != nil {
+		return fmt.Errorf("failed to get position for stop-loss: %w", err)
+	}
+	if reducesPosition(signal, position.Size) {
+		signal.StopLossPrice, signal.TakeProfitPrice = 0, 0
+	}
+	return nil
+}
+
+// reducesPosition reports whether an order only reduces a position of size,
+// closing it at most. One that flips the position opens the other side.
+func reducesPosition(signal *models.TradingSignal, size float64) bool {
+	switch signal.Side {
+	case "BUY":
+		return size < 0 && signal.Quantity <= -size
+	case "SELL":
+		return size > 0 && signal.Quantity <= size
+	}
+	return false
+}
@@ -0,0 +1,60 @@
+package processor
+
+import (
+	"context"
+	"crypto-sync-bot/internal/config"
+	"crypto-sync-bot/internal/models"
+	"math"
+	"testing"
+)
+
+func TestProtectOrder(t *testing.T) {
+	tests := []struct {
+		name        string
+		conditional bool
+		position    float64
+		side        string
+		quantity    float64
+		stopLoss    float64 // set on the signal
+		takeProfit  float64
+
+		wantStopLoss   float64
+		wantTakeProfit float64
+	}{
+		{name: "opening buy gets derived stop", conditional: true, side: "BUY", quantity: 1, wantStopLoss: 95},
+		{name: "opening sell gets derived stop", conditional: true, side: "SELL", quantity: 1, wantStopLoss: 105},
+		{name: "adding to long is protected", conditional: true, position: 2, side: "BUY", quantity: 1, wantStopLoss: 95},
+		{name: "explicit prices are kept", conditional: true, side: "BUY", quantity: 1, stopLoss: 90, takeProfit: 120, wantStopLoss: 90, wantTakeProfit: 120},
+		{name: "sell reducing long is bare", conditional: true, position: 2, side: "SELL", quantity: 1},
+		{name: "sell closing long is bare", conditional: true, position: 2, side: "SELL", quantity: 2, takeProfit: 80},
+		{name: "buy reducing short is bare", conditional: true, position: -2, side: "BUY", quantity: 1},
+		{name: "sell flipping long opens short", conditional: true, position: 1, side: "SELL", quantity: 3, wantStopLoss: 105},
+		{name: "unsupported executor is bare", side: "BUY", quantity: 1, stopLoss: 90},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exec := newFakeExecutor("target")
+			exec.conditional = tt.conditional
+			exec.position = &models.Position{Symbol: "BTC-USDT", Size: tt.position}
+			p := NewSignalProcessor(&config.Config{Sync: config.SyncConfig{StopLossRatio: 0.05}}, nil)
+			signal := &models.TradingSignal{
+				Symbol:          "BTC-USDT",
+				Side:            tt.side,
+				Quantity:        tt.quantity,
+				Price:           100,
+				StopLossPrice:   tt.stopLoss,
+				TakeProfitPrice: tt.takeProfit,
+			}
+			if err := p.protectOrder(context.Background(), exec, signal); err != nil {
+				t.Fatalf("protectOrder: %v", err)
+			}
+			if math.Abs(signal.StopLossPrice-tt.wantStopLoss) > 1e-9 {
+				t.Errorf("stop-loss %v, want %v", signal.StopLossPrice, tt.wantStopLoss)
+			}
+			if signal.TakeProfitPrice != tt.wantTakeProfit {
+				t.Errorf("take-profit %v, want %v", signal.TakeProfitPrice, tt.wantTakeProfit)
+			}
+		})
+	}
+}
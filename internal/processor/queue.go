@@ -0,0 +1,98 @@
+package processor
+
+import (
+	"context"
+	"crypto-sync-bot/internal/config"
+	"crypto-sync-bot/internal/database"
+	"errors"
+	"log"
+	"strings"
+	"time"
+)
+
+var ErrQueueUnavailable = errors.New("signal queue not available")
+
+// Message is a signal delivered by the queue
+type Message struct {
+	ID         string
+	Partition  string // where the message was read from; only meaningful to the queue
+	Payload    string
+	Targets    []string // target accounts a replay is restricted to, nil for all
+	Deliveries int64    // how many times it has been delivered, this one included
+}
+
+// SignalQueue carries signals from the listeners to the processor. A message
+// stays pending from Read until it is acked or dead-lettered; pending messages
+// are handed out again by Reclaim, so a signal survives failed attempts and
+// crashes of the process working on it.
+type SignalQueue interface {
+	Name() string
+	Publish(ctx context.Context, payload string, targets []string) error
+	// Read returns up to count new messages, waiting at most block for one to arrive
+	Read(ctx context.Context, count int64, block time.Duration) ([]Message, error)
+	Ack(ctx context.Context, msg Message) error
+	// OwnsSymbol reports whether this instance consumes the signals for
+	// symbol, so periodic work on a symbol runs on one replica only
+	OwnsSymbol(symbol string) bool
+	// Reclaim delivers again the pending messages that due accepts, given how
+	// often each was delivered and how long ago the last delivery was
+	Reclaim(ctx context.Context, due func(deliveries int64, idle time.Duration) bool) ([]Message, error)
+
+	// DeadLetter moves a message to the DLQ, with the last error of each failed target
+	DeadLetter(ctx context.Context, msg Message, errs map[string]string, reason string) error
+	ListDLQ(ctx context.Context, after string, count int64) ([]DLQEntry, error)
+	GetDLQ(ctx context.Context, id string) (*DLQEntry, error)
+	// Requeue publishes a DLQ entry again, restricted to targets if non-empty, and removes it from the DLQ
+	Requeue(ctx context.Context, entry *DLQEntry, targets []string) error
+	DeleteDLQ(ctx context.Context, ids ...string) (int64, error)
+	PurgeDLQ(ctx context.Context) (int64, error)
+
+	// Close hands back anything this instance holds on to for consuming
+	Close(ctx context.Context)
+}
+
+var (
+	signalQueue SignalQueue
+	signalState StateStore
+)
+
+// InitQueue selects the signal queue: Redis Streams when Redis is configured,
+// otherwise the embedded SQLite queue so a single node runs without Redis.
+// It must be called before any signal is produced or consumed.
+func InitQueue(cfg *config.Config) {
+	if database.RDB != nil {
+		queue := newRedisQueue(consumerName(), cfg.GetSync().StreamPartitions, pendingIdle(cfg))
+		queue.ensureGroups(context.Background())
+		signalQueue, signalState = queue, redisState{}
+		return
+	}
+	if database.DB != nil {
+		signalQueue, signalState = newSQLiteQueue(), sqliteState{}
+		log.Println("Using the embedded SQLite signal queue")
+		return
+	}
+	log.Println("Warning: no signal queue available (neither Redis nor SQLite)")
+}
+
+// QueueAvailable reports whether signals can be queued and their state tracked
+func QueueAvailable() bool {
+	return signalQueue != nil
+}
+
+func joinTargets(targets []string) string {
+	normalized := make([]string, 0, len(targets))
+	for _, target := range targets {
+		if target = strings.ToLower(strings.TrimSpace(target)); target != "" {
+			normalized = append(normalized, target)
+		}
+	}
+	return strings.Join(normalized, ",")
+}
+
+// splitTargets parses a stored target restriction; empty means every target
+func splitTargets(raw string) []string {
+	if raw == "" {
+		return nil
+	}
+	return strings.Split(raw, ",")
+}
@@ -0,0 +1,346 @@
+package processor
+
+import (
+	"context"
+	"crypto-sync-bot/internal/database"
+	"crypto-sync-bot/internal/models"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// redisQueue is the SignalQueue on Redis Streams, partitioned by symbol and
+// consumed through a consumer group by any number of replicas
+type redisQueue struct {
+	consumer   string // consumer name within consumerGroup, unique per replica
+	partitions int
+	minIdle    time.Duration // idle time after which a pending signal counts as abandoned
+
+	owned         map[int]bool // leased partitions
+	ownedMu       sync.Mutex
+	lastRebalance time.Time
+	leases        sync.Once
+	stop          chan struct{}
+}
+
+func newRedisQueue(consumer string, partitions int, minIdle time.Duration) *redisQueue {
+	if partitions < 1 {
+		partitions = 1
+	}
+	return &redisQueue{
+		consumer:   consumer,
+		partitions: partitions,
+		minIdle:    minIdle,
+		owned:      make(map[int]bool),
+		stop:       make(chan struct{}),
+	}
+}
+
+func (q *redisQueue) Name() string {
+	return fmt.Sprintf("Redis Stream Consumer %s", q.consumer)
+}
+
+func (q *redisQueue) Publish(ctx context.Context, payload string, targets []string) error {
+	return q.add(ctx, payload, targets, nil)
+}
+
+func (q *redisQueue) add(ctx context.Context, payload string, targets []string, extra map[string]interface{}) error {
+	var signal models.TradingSignal
+	if err := json.Unmarshal([]byte(payload), &signal); err != nil {
+		return fmt.Errorf("invalid payload: %w", err)
+	}
+	values := map[string]interface{}{
+		"payload":     payload,
+		"retry_count": 0,
+	}
+	if joined := joinTargets(targets); joined != "" {
+		values["targets"] = joined
+	}
+	for k, v := range extra {
+		values[k] = v
+	}
+	return database.RDB.XAdd(ctx, &redis.XAddArgs{
+		Stream: q.streamForSymbol(signal.Symbol),
+		Values: values,
+	}).Err()
+}
+
+// Read only consumes from the partitions leased to this instance. Consuming
+// starts the lease loop; it rebalances between reads, so a partition is never
+// released while its signals are in flight. Signals abandoned on a newly
+// leased partition are returned ahead of new ones.
+func (q *redisQueue) Read(ctx context.Context, count int64, block time.Duration) ([]Message, error) {
+	q.leases.Do(func() { go q.runLeases() })
+
+	var claimed []Message
+	for _, stream := range q.rebalance(ctx) {
+		// The previous owner may have crashed with signals in flight
+		claimed = append(claimed, q.claimAbandoned(ctx, stream)...)
+	}
+	if len(claimed) > 0 {
+		return claimed, nil
+	}
+
+	owned := q.ownedStreams()
+	if len(owned) == 0 {
+		// Every partition is leased to other replicas; stand by
+		select {
+		case <-q.stop:
+		case <-ctx.Done():
+		case <-time.After(leaseRenewInterval):
+		}
+		return nil, nil
+	}
+
+	streams := append([]string{}, owned...)
+	for range owned {
+		streams = append(streams, ">")
+	}
+	entries, err := database.RDB.XReadGroup(ctx, &redis.XReadGroupArgs{
+		Group:    consumerGroup,
+		Consumer: q.consumer,
+		Streams:  streams,
+		Count:    count,
+		Block:    block,
+	}).Result()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var msgs []Message
+	for _, stream := range entries {
+		for _, entry := range stream.Messages {
+			msgs = append(msgs, toMessage(stream.Stream, entry, 1))
+		}
+	}
+	return msgs, nil
+}
+
+func toMessage(stream string, entry redis.XMessage, deliveries int64) Message {
+	payload, _ := entry.Values["payload"].(string)
+	targets, _ := entry.Values["targets"].(string)
+	return Message{
+		ID:         entry.ID,
+		Partition:  stream,
+		Payload:    payload,
+		Targets:    splitTargets(targets),
+		Deliveries: deliveries,
+	}
+}
+
+func (q *redisQueue) Ack(ctx context.Context, msg Message) error {
+	return database.RDB.XAck(ctx, msg.Partition, consumerGroup, msg.ID).Err()
+}
+
+// claimAbandoned takes over every pending signal in stream that has been idle
+// longer than the threshold
+func (q *redisQueue) claimAbandoned(ctx context.Context, stream string) []Message {
+	var claimed []Message
+	start := "0-0"
+	for {
+		entries, next, err := database.RDB.XAutoClaim(ctx, &redis.XAutoClaimArgs{
+			Stream:   stream,
+			Group:    consumerGroup,
+			Consumer: q.consumer,
+			MinIdle:  q.minIdle,
+			Start:    start,
+			Count:    recoveryBatch,
+		}).Result()
+		if err != nil {
+			log.Printf("XAutoClaim Error: %v", err)
+			break
+		}
+		claimed = append(claimed, q.claimedMessages(ctx, stream, entries)...)
+		if next == "0-0" || next == "" {
+			break
+		}
+		start = next
+	}
+	if len(claimed) > 0 {
+		log.Printf("Recovered %d pending signal(s) from %s", len(claimed), stream)
+	}
+	return claimed
+}
+
+// Reclaim claims the pending signals on the leased partitions that due accepts
+func (q *redisQueue) Reclaim(ctx context.Context, due func(deliveries int64, idle time.Duration) bool) ([]Message, error) {
+	var claimed []Message
+	for _, stream := range q.ownedStreams() {
+		pending, err := database.RDB.XPendingExt(ctx, &redis.XPendingExtArgs{
+			Stream: stream,
+			Group:  consumerGroup,
+			Idle:   q.minIdle,
+			Start:  "-",
+			End:    "+",
+			Count:  recoveryBatch,
+		}).Result()
+		if err != nil {
+			return claimed, err
+		}
+
+		for _, entry := range pending {
+			if !due(entry.RetryCount, entry.Idle) {
+				continue
+			}
+			entries, err := database.RDB.XClaim(ctx, &redis.XClaimArgs{
+				Stream:   stream,
+				Group:    consumerGroup,
+				Consumer: q.consumer,
+				MinIdle:  entry.Idle, // another consumer that touched it since loses nothing
+				Messages: []string{entry.ID},
+			}).Result()
+			if err != nil {
+				log.Printf("XClaim Error for %s: %v", entry.ID, err)
+				continue
+			}
+			claimed = append(claimed, q.claimedMessages(ctx, stream, entries)...)
+		}
+	}
+	return claimed, nil
+}
+
+// claimedMessages converts claimed entries with their delivery counts. Entries
+// trimmed from the stream have nothing left to retry and are acked.
+func (q *redisQueue) claimedMessages(ctx context.Context, stream string, entries []redis.XMessage) []Message {
+	msgs := make([]Message, 0, len(entries))
+	for _, entry := range entries {
+		if entry.Values == nil {
+			database.RDB.XAck(ctx, stream, consumerGroup, entry.ID)
+			continue
+		}
+		deliveries := int64(1)
+		pending, err := database.RDB.XPendingExt(ctx, &redis.XPendingExtArgs{
+			Stream: stream,
+			Group:  consumerGroup,
+			Start:  entry.ID,
+			End:    entry.ID,
+			Count:  1,
+		}).Result()
+		if err == nil && len(pending) > 0 {
+			deliveries = pending[0].RetryCount
+		}
+		msgs = append(msgs, toMessage(stream, entry, deliveries))
+	}
+	return msgs
+}
+
+func (q *redisQueue) DeadLetter(ctx context.Context, msg Message, errs map[string]string, reason string) error {
+	values := map[string]interface{}{
+		"payload":    msg.Payload,
+		"stream":     msg.Partition,
+		"message_id": msg.ID,
+		"deliveries": msg.Deliveries,
+		"failed_at":  time.Now().UnixMilli(),
+		"reason":     reason,
+	}
+	if joined := joinTargets(msg.Targets); joined != "" {
+		values["targets"] = joined
+	}
+	if len(errs) > 0 {
+		data, _ := json.Marshal(errs)
+		values["errors"] = string(data)
+	}
+
+	if err := database.RDB.XAdd(ctx, &redis.XAddArgs{
+		Stream: dlqStream,
+		Values: values,
+	}).Err(); err != nil {
+		return err
+	}
+	return q.Ack(ctx, msg)
+}
+
+func (q *redisQueue) ListDLQ(ctx context.Context, after string, count int64) ([]DLQEntry, error) {
+	start := "-"
+	if after != "" {
+		start = "(" + after
+	}
+	msgs, err := database.RDB.XRangeN(ctx, dlqStream, start, "+", count).Result()
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]DLQEntry, 0, len(msgs))
+	for _, msg := range msgs {
+		entries = append(entries, decodeDLQEntry(msg))
+	}
+	return entries, nil
+}
+
+func (q *redisQueue) GetDLQ(ctx context.Context, id string) (*DLQEntry, error) {
+	msgs, err := database.RDB.XRange(ctx, dlqStream, id, id).Result()
+	if err != nil {
+		return nil, err
+	}
+	if len(msgs) == 0 {
+		return nil, ErrDLQEntryNotFound
+	}
+	entry := decodeDLQEntry(msgs[0])
+	return &entry, nil
+}
+
+func (q *redisQueue) Requeue(ctx context.Context, entry *DLQEntry, targets []string) error {
+	if err := q.add(ctx, entry.payload, targets, map[string]interface{}{"replay_of": entry.ID}); err != nil {
+		return err
+	}
+	return database.RDB.XDel(ctx, dlqStream, entry.ID).Err()
+}
+
+func (q *redisQueue) DeleteDLQ(ctx context.Context, ids ...string) (int64, error) {
+	return database.RDB.XDel(ctx, dlqStream, ids...).Result()
+}
+
+func (q *redisQueue) PurgeDLQ(ctx context.Context) (int64, error) {
+	n, err := database.RDB.XLen(ctx, dlqStream).Result()
+	if err != nil {
+		return 0, err
+	}
+	return n, database.RDB.XTrimMaxLen(ctx, dlqStream, 0).Err()
+}
+
+func (q *redisQueue) Close(ctx context.Context) {
+	close(q.stop)
+	q.releaseLeases(ctx)
+}
+
+func decodeDLQEntry(msg redis.XMessage) DLQEntry {
+	payload, _ := msg.Values["payload"].(string)
+	targets, _ := msg.Values["targets"].(string)
+	errs, _ := msg.Values["errors"].(string)
+	entry := newDLQEntry(msg.ID, payload, targets, errs)
+	entry.FailedAt = streamIDTime(msg.ID)
+
+	entry.Reason, _ = msg.Values["reason"].(string)
+	entry.Stream, _ = msg.Values["stream"].(string)
+	entry.MessageID, _ = msg.Values["message_id"].(string)
+	if entry.MessageID != "" {
+		entry.QueuedAt = streamIDTime(entry.MessageID)
+	}
+	if s, ok := msg.Values["deliveries"].(string); ok {
+		entry.Deliveries, _ = strconv.ParseInt(s, 10, 64)
+	}
+	if s, ok := msg.Values["failed_at"].(string); ok {
+		if ms, err := strconv.ParseInt(s, 10, 64); err == nil {
+			entry.FailedAt = time.UnixMilli(ms)
+		}
+	}
+	return entry
+}
+
+// streamIDTime returns the time encoded in a stream entry ID ("<ms>-<seq>")
+func streamIDTime(id string) time.Time {
+	ms, _, _ := strings.Cut(id, "-")
+	n, err := strconv.ParseInt(ms, 10, 64)
+	if err != nil {
+		return time.Time{}
+	}
+	return time.UnixMilli(n)
+}
@@ -0,0 +1,211 @@
+package processor
+
+import (
+	"context"
+	"crypto-sync-bot/internal/database"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// sqliteQueue is the embedded SignalQueue for single-node deployments without
+// Redis. Signals are rows in the local SQLite database, so they survive
+// restarts; anything delivered but not acked when the process stopped is
+// reclaimed like a pending stream entry.
+type sqliteQueue struct {
+	notify chan struct{} // wakes up a blocked Read when a signal is published
+}
+
+// sqlitePollInterval bounds how long a published signal can wait for a blocked
+// Read when it was written by another process sharing the database file
+const sqlitePollInterval = 500 * time.Millisecond
+
+func newSQLiteQueue() *sqliteQueue {
+	return &sqliteQueue{notify: make(chan struct{}, 1)}
+}
+
+func (q *sqliteQueue) Name() string {
+	return "Embedded SQLite Queue"
+}
+
+func (q *sqliteQueue) Publish(ctx context.Context, payload string, targets []string) error {
+	if _, err := database.EnqueueSignal(payload, joinTargets(targets), time.Now().UnixMilli()); err != nil {
+		return err
+	}
+	select {
+	case q.notify <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+func (q *sqliteQueue) Read(ctx context.Context, count int64, block time.Duration) ([]Message, error) {
+	deadline := time.Now().Add(block)
+	for {
+		queued, err := database.DeliverQueued(count, time.Now().UnixMilli())
+		if err != nil || len(queued) > 0 {
+			return toMessages(queued), err
+		}
+
+		wait := time.Until(deadline)
+		if wait <= 0 {
+			return nil, nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-q.notify:
+		case <-time.After(min(wait, sqlitePollInterval)):
+		}
+	}
+}
+
+func toMessages(queued []database.QueuedSignal) []Message {
+	msgs := make([]Message, len(queued))
+	for i, s := range queued {
+		msgs[i] = Message{
+			ID:         strconv.FormatInt(s.ID, 10),
+			Payload:    s.Payload,
+			Targets:    splitTargets(s.Targets),
+			Deliveries: s.Deliveries,
+		}
+	}
+	return msgs
+}
+
+// OwnsSymbol is always true: the embedded queue has a single consumer
+func (q *sqliteQueue) OwnsSymbol(symbol string) bool {
+	return true
+}
+
+func (q *sqliteQueue) Ack(ctx context.Context, msg Message) error {
+	id, err := strconv.ParseInt(msg.ID, 10, 64)
+	if err != nil {
+		return err
+	}
+	return database.AckQueued(id)
+}
+
+func (q *sqliteQueue) Reclaim(ctx context.Context, due func(deliveries int64, idle time.Duration) bool) ([]Message, error) {
+	pending, err := database.PendingQueued(recoveryBatch)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	var claimed []database.QueuedSignal
+	for _, s := range pending {
+		if !due(s.Deliveries, now.Sub(time.UnixMilli(s.DeliveredAt))) {
+			continue
+		}
+		ok, err := database.RedeliverQueued(&s, now.UnixMilli())
+		if err != nil {
+			return toMessages(claimed), err
+		}
+		if ok {
+			claimed = append(claimed, s)
+		}
+	}
+	return toMessages(claimed), nil
+}
+
+func (q *sqliteQueue) DeadLetter(ctx context.Context, msg Message, errs map[string]string, reason string) error {
+	id, err := strconv.ParseInt(msg.ID, 10, 64)
+	if err != nil {
+		return err
+	}
+	letter := &database.DeadLetter{
+		Payload:    msg.Payload,
+		Targets:    joinTargets(msg.Targets),
+		Reason:     reason,
+		Deliveries: msg.Deliveries,
+		MessageID:  msg.ID,
+		FailedAt:   time.Now().UnixMilli(),
+	}
+	if len(errs) > 0 {
+		data, _ := json.Marshal(errs)
+		letter.Errors = string(data)
+	}
+	if queued, err := database.GetQueued(id); err == nil {
+		letter.QueuedAt = queued.CreatedAt
+	}
+	return database.MoveToDeadLetter(id, letter)
+}
+
+func (q *sqliteQueue) ListDLQ(ctx context.Context, after string, count int64) ([]DLQEntry, error) {
+	var afterID int64
+	if after != "" {
+		var err error
+		if afterID, err = strconv.ParseInt(after, 10, 64); err != nil {
+			return nil, fmt.Errorf("invalid dlq entry id %q", after)
+		}
+	}
+	letters, err := database.ListDeadLetters(afterID, count)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]DLQEntry, len(letters))
+	for i := range letters {
+		entries[i] = toDLQEntry(&letters[i])
+	}
+	return entries, nil
+}
+
+func (q *sqliteQueue) GetDLQ(ctx context.Context, id string) (*DLQEntry, error) {
+	n, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return nil, ErrDLQEntryNotFound
+	}
+	letter, err := database.GetDeadLetter(n)
+	if err == sql.ErrNoRows {
+		return nil, ErrDLQEntryNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	entry := toDLQEntry(letter)
+	return &entry, nil
+}
+
+func toDLQEntry(d *database.DeadLetter) DLQEntry {
+	entry := newDLQEntry(strconv.FormatInt(d.ID, 10), d.Payload, d.Targets, d.Errors)
+	entry.Reason = d.Reason
+	entry.Deliveries = d.Deliveries
+	entry.MessageID = d.MessageID
+	entry.QueuedAt = time.UnixMilli(d.QueuedAt)
+	entry.FailedAt = time.UnixMilli(d.FailedAt)
+	return entry
+}
+
+func (q *sqliteQueue) Requeue(ctx context.Context, entry *DLQEntry, targets []string) error {
+	id, err := strconv.ParseInt(entry.ID, 10, 64)
+	if err != nil {
+		return ErrDLQEntryNotFound
+	}
+	if err := database.RequeueDeadLetter(id, joinTargets(targets), time.Now().UnixMilli()); err != nil {
+		return err
+	}
+	select {
+	case q.notify <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+func (q *sqliteQueue) DeleteDLQ(ctx context.Context, ids ...string) (int64, error) {
+	parsed := make([]int64, 0, len(ids))
+	for _, id := range ids {
+		if n, err := strconv.ParseInt(id, 10, 64); err == nil {
+			parsed = append(parsed, n)
+		}
+	}
+	return database.DeleteDeadLetters(parsed...)
+}
+
+func (q *sqliteQueue) PurgeDLQ(ctx context.Context) (int64, error) {
+	return database.PurgeDeadLetters()
+}
+
+func (q *sqliteQueue) Close(ctx context.Context) {}
@@ -0,0 +1,100 @@
+package processor
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestSQLiteQueueLifecycle(t *testing.T) {
+	useSQLite(t)
+	ctx := context.Background()
+	q := signalQueue
+
+	if err := q.Publish(ctx, `{"symbol":"BTC-USDT","signal_id":"1"}`, nil); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+	if err := q.Publish(ctx, `{"symbol":"ETH-USDT","signal_id":"2"}`, []string{"bybit", "okx"}); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+
+	// Deliver: both come out once, oldest first, and are pending afterwards
+	msgs, err := q.Read(ctx, 10, 0)
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if len(msgs) != 2 {
+		t.Fatalf("Read returned %d messages, want 2", len(msgs))
+	}
+	first, second := msgs[0], msgs[1]
+	if first.Deliveries != 1 || first.Targets != nil {
+		t.Errorf("first message %+v, want one delivery and no targets", first)
+	}
+	if !equalStrings(second.Targets, []string{"bybit", "okx"}) {
+		t.Errorf("second message targets %v, want [bybit okx]", second.Targets)
+	}
+	if again, err := q.Read(ctx, 10, 0); err != nil || len(again) != 0 {
+		t.Fatalf("second Read = %v, %v; want nothing new", again, err)
+	}
+
+	// Redeliver: only what due accepts, counting the delivery
+	none := func(int64, time.Duration) bool { return false }
+	all := func(int64, time.Duration) bool { return true }
+	if reclaimed, err := q.Reclaim(ctx, none); err != nil || len(reclaimed) != 0 {
+		t.Fatalf("Reclaim(none) = %v, %v; want nothing", reclaimed, err)
+	}
+	reclaimed, err := q.Reclaim(ctx, all)
+	if err != nil {
+		t.Fatalf("Reclaim: %v", err)
+	}
+	if len(reclaimed) != 2 || reclaimed[0].ID != first.ID || reclaimed[0].Deliveries != 2 {
+		t.Fatalf("Reclaim = %+v, want both messages on their second delivery", reclaimed)
+	}
+
+	// Ack: the first is gone for good
+	if err := q.Ack(ctx, reclaimed[0]); err != nil {
+		t.Fatalf("Ack: %v", err)
+	}
+
+	// Dead-letter: the second leaves the queue with its errors and targets
+	errs := map[string]string{"okx": "insufficient margin"}
+	if err := q.DeadLetter(ctx, reclaimed[1], errs, "retries exhausted"); err != nil {
+		t.Fatalf("DeadLetter: %v", err)
+	}
+	if pending, err := q.Reclaim(ctx, all); err != nil || len(pending) != 0 {
+		t.Fatalf("Reclaim after ack and dead-letter = %v, %v; want nothing", pending, err)
+	}
+
+	entries, err := q.ListDLQ(ctx, "", 10)
+	if err != nil {
+		t.Fatalf("ListDLQ: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("ListDLQ returned %d entries, want 1", len(entries))
+	}
+	entry := entries[0]
+	if entry.MessageID != second.ID || entry.Deliveries != 2 || entry.Reason != "retries exhausted" {
+		t.Errorf("DLQ entry %+v does not describe the dead-lettered message", entry)
+	}
+	if entry.Signal == nil || entry.Signal.SignalID != "2" {
+		t.Errorf("DLQ entry signal %+v, want signal 2", entry.Signal)
+	}
+	if entry.Errors["okx"] != "insufficient margin" || !equalStrings(entry.Targets, []string{"bybit", "okx"}) {
+		t.Errorf("DLQ entry errors %v targets %v", entry.Errors, entry.Targets)
+	}
+
+	// Requeue: the entry is published again, restricted to the failed target
+	if err := q.Requeue(ctx, &entry, []string{"okx"}); err != nil {
+		t.Fatalf("Requeue: %v", err)
+	}
+	if entries, err := q.ListDLQ(ctx, "", 10); err != nil || len(entries) != 0 {
+		t.Fatalf("ListDLQ after requeue = %v, %v; want empty", entries, err)
+	}
+	msgs, err = q.Read(ctx, 10, 0)
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if len(msgs) != 1 || msgs[0].Payload != second.Payload || msgs[0].Deliveries != 1 || !equalStrings(msgs[0].Targets, []string{"okx"}) {
+		t.Fatalf("Read after requeue = %+v, want the signal again for okx only", msgs)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"crypto-sync-bot/internal/database"
 	"crypto-sync-bot/internal/models"
 	"log"
+	"strings"
 	"time"
 )
 
@@ -12,12 +13,10 @@ type Reconciler struct {
 	executors map[string]models.ExchangeExecutor
 }
 
-func NewReconciler(execs []models.ExchangeExecutor) *Reconciler {
-	m := make(map[string]models.ExchangeExecutor)
-	for _, e := range execs {
-		m[e.Name()] = e
-	}
-	return &Reconciler{executors: m}
+// NewReconciler takes the executors keyed by account ID, which is what the
+// processor stores in the orders table
+func NewReconciler(execs map[string]models.ExchangeExecutor) *Reconciler {
+	return &Reconciler{executors: execs}
 }
 
 func (r *Reconciler) Start(ctx context.Context) {
@@ -31,15 +30,15 @@ func (r *Reconciler) Start(ctx context.Context) {
 			log.Println("Reconciler stopping")
 			return
 		case <-ticker.C:
-			r.reconcileOrders()
+			r.reconcileOrders(ctx)
 		}
 	}
 }
 
-func (r *Reconciler) reconcileOrders() {
+func (r *Reconciler) reconcileOrders(ctx context.Context) {
 	// Query non-final orders. 
 	// We include 'success' because PlaceOrder might return 'success' but the order is still 'NEW' or 'PARTIALLY_FILLED' on the exchange.
-	rows, err := database.DB.Query("SELECT exchange, symbol, order_id FROM orders WHERE status NOT IN ('FILLED', 'CANCELLED', 'REJECTED', 'failed') AND order_id != ''")
+	rows, err := database.DB.Query("SELECT exchange, symbol, order_id FROM orders WHERE status NOT IN ('FILLED', 'CANCELLED', 'REJECTED', 'failed', 'skipped') AND order_id != ''")
 	if err != nil {
 		log.Printf("Reconciler: failed to query orders: %v", err)
 		return
@@ -47,25 +46,31 @@ func (r *Reconciler) reconcileOrders() {
 	defer rows.Close()
 
 	for rows.Next() {
+		if ctx.Err() != nil {
+			return
+		}
 		var exchange, symbol, orderID string
 		if err := rows.Scan(&exchange, &symbol, &orderID); err != nil {
 			log.Printf("Reconciler: failed to scan row: %v", err)
 			continue
 		}
 
-		exec, ok := r.executors[exchange]
+		// Rows written before accounts existed hold the display name (e.g. "OKX"),
+		// which lower-cases to the migrated account ID
+		exec, ok := r.executors[strings.ToLower(exchange)]
 		if !ok {
 			log.Printf("Reconciler: executor not found for %s", exchange)
 			continue
 		}
 
-		res, err := exec.GetOrder(orderID, symbol)
+		res, err := exec.GetOrder(ctx, orderID, symbol)
 		if err != nil {
 			log.Printf("Reconciler: failed to get order %s from %s: %v", orderID, exchange, err)
 			continue
 		}
 
-		_, err = database.DB.Exec("UPDATE orders SET status = ? WHERE order_id = ?", res.Status, orderID)
+		_, err = database.DB.Exec("UPDATE orders SET status = ?, filled_quantity = ?, avg_price = ? WHERE order_id = ?",
+			res.Status, res.FilledQuantity, res.AvgPrice, orderID)
 		if err != nil {
 			log.Printf("Reconciler: failed to update order %s in DB: %v", orderID, err)
 		} else {
@@ -0,0 +1,92 @@
+package processor
+
+import (
+	"context"
+	"crypto-sync-bot/internal/config"
+	"log"
+	"sync"
+	"time"
+)
+
+const (
+	recoveryInterval = 10 * time.Second
+	recoveryBatch    = 100
+	// maxRetryBackoff caps how long a repeatedly failing signal waits between attempts
+	maxRetryBackoff = 10 * time.Minute
+)
+
+// maxRetries is how many times a failed signal is redelivered before it goes to the DLQ
+func (p *SignalProcessor) maxRetries() int64 {
+	if n := p.config.GetSync().MaxRetries; n > 0 {
+		return int64(n)
+	}
+	return 3
+}
+
+// pendingIdle is how long a delivered signal may stay unacknowledged before it is retried
+func pendingIdle(cfg *config.Config) time.Duration {
+	if n := cfg.GetSync().PendingIdleTimeout; n > 0 {
+		return time.Duration(n) * time.Second
+	}
+	return 60 * time.Second
+}
+
+// retryBackoff is the idle time required before the next attempt of a signal
+// that has already been delivered deliveries times. It doubles per attempt.
+func (p *SignalProcessor) retryBackoff(deliveries int64) time.Duration {
+	backoff := pendingIdle(p.config)
+	for i := int64(1); i < deliveries && backoff < maxRetryBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > maxRetryBackoff {
+		backoff = maxRetryBackoff
+	}
+	return backoff
+}
+
+// recoverPending retries signals left unacknowledged in the queue, whether
+// they failed here or were delivered to a process that crashed. The pending
+// signals are rescanned periodically with exponential backoff per signal;
+// those that used up their retries are reclaimed right away for the DLQ.
+func (p *SignalProcessor) recoverPending() {
+	ctx := context.Background()
+	ticker := time.NewTicker(recoveryInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.stopChan:
+			return
+		case <-ticker.C:
+		}
+
+		msgs, err := signalQueue.Reclaim(ctx, func(deliveries int64, idle time.Duration) bool {
+			if idle < pendingIdle(p.config) {
+				return false // may still be in flight
+			}
+			return deliveries > p.maxRetries() || idle >= p.retryBackoff(deliveries)
+		})
+		if err != nil {
+			log.Printf("Failed to reclaim pending signals: %v", err)
+		}
+		if len(msgs) == 0 {
+			continue
+		}
+		var done sync.WaitGroup
+		for _, msg := range msgs {
+			log.Printf("Retrying pending signal %s", msg.ID)
+			p.deliver(ctx, msg, &done)
+		}
+		done.Wait()
+	}
+}
+
+// deliver dispatches a message to its worker, or sends it straight to the DLQ
+// once it has used up its retries (e.g. it keeps crashing the consumer)
+func (p *SignalProcessor) deliver(ctx context.Context, msg Message, done *sync.WaitGroup) {
+	if msg.Deliveries > p.maxRetries()+1 {
+		msg.Deliveries-- // this delivery was never attempted
+		p.moveToDLQ(ctx, msg, nil)
+		return
+	}
+	p.dispatch(msg, done)
+}
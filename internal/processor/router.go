@@ -0,0 +1,88 @@
+package processor
+
+import (
+	"crypto-sync-bot/internal/config"
+	"crypto-sync-bot/internal/models"
+	"strings"
+)
+
+// MatchSyncItems returns the enabled sync items whose source and symbol match the signal.
+// Symbols are compared in canonical form, so BTC-USDT matches BTCUSDT; an item
+// with an empty or wildcard symbol matches every symbol.
+func MatchSyncItems(items []config.SyncItem, signal *models.TradingSignal) []config.SyncItem {
+	symbol, err := models.ParseSymbol(signal.Symbol)
+	if err != nil {
+		return nil
+	}
+
+	var matched []config.SyncItem
+	for _, item := range items {
+		if !item.Enabled {
+			continue
+		}
+		if !strings.EqualFold(item.Source, signal.Source) {
+			continue
+		}
+		if item.AllSymbols() {
+			matched = append(matched, item)
+			continue
+		}
+		itemSymbol, err := models.ParseSymbol(item.Symbol)
+		if err != nil || itemSymbol != symbol {
+			continue
+		}
+		matched = append(matched, item)
+	}
+	return matched
+}
+
+// orderMirrorItems keeps the items that copy resting limit orders as they are placed
+func orderMirrorItems(items []config.SyncItem) []config.SyncItem {
+	var mirrored []config.SyncItem
+	for _, item := range items {
+		if item.MirrorsOrders() {
+			mirrored = append(mirrored, item)
+		}
+	}
+	return mirrored
+}
+
+// route is one target a signal is sent to, along with the sync item that selected it
+type route struct {
+	executor models.ExchangeExecutor
+	item     config.SyncItem
+}
+
+// routeTargets returns the executors the signal should be sent to, keyed by
+// exchange ID. Targets of every matching sync item are merged (the first item
+// naming a target wins); targets without a configured executor are reported in missing.
+func (p *SignalProcessor) routeTargets(items []config.SyncItem) (targets map[string]route, missing []string) {
+	targets = make(map[string]route)
+	for _, item := range items {
+		for _, id := range item.Targets {
+			id = strings.ToLower(id)
+			if _, ok := targets[id]; ok {
+				continue
+			}
+			executor, ok := p.executors[id]
+			if !ok {
+				missing = append(missing, id)
+				continue
+			}
+			targets[id] = route{executor: executor, item: item}
+		}
+	}
+	return targets, missing
+}
+
+// restrictTargets keeps only the routes for the given account IDs, as used by
+// DLQ replays aimed at the targets that failed
+func restrictTargets(targets map[string]route, only []string) map[string]route {
+	restricted := make(map[string]route, len(only))
+	for _, id := range only {
+		if target, ok := targets[id]; ok {
+			restricted[id] = target
+		}
+	}
+	return restricted
+}
@@ -7,285 +7,388 @@ import (
 	"crypto-sync-bot/internal/metrics"
 	"crypto-sync-bot/internal/models"
 	"crypto-sync-bot/internal/risk"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"fmt"
 	"log"
 	"sync"
 	"time"
+)
+
+const (
+	signalStream  = "signals:trading"
+	consumerGroup = "trading-group"
+	dlqStream     = "signals:dlq"
+)
 
-	"github.com/redis/go-redis/v9"
+const (
+	// drainTimeout is how long Stop waits for in-flight signals before aborting them
+	drainTimeout = 30 * time.Second
+	// abortGrace is how long Stop then waits for aborted work to unwind
+	abortGrace = 5 * time.Second
 )
 
 type SignalProcessor struct {
-	okxExecutor      models.ExchangeExecutor
-	bybitExecutor    models.ExchangeExecutor
-	backpackExecutor models.ExchangeExecutor
-	lighterExecutor  models.ExchangeExecutor
-	riskManager      *risk.Manager
-	config           *config.Config
-	stopChan         chan struct{}
+	executors   map[string]models.ExchangeExecutor // keyed by account ID, e.g. "okx"
+	sources     map[string]models.BalanceProvider  // signal sources, for equity sizing
+	sourcesMu   sync.RWMutex
+	riskManager *risk.Manager
+	config      *config.Config
+	stopChan    chan struct{}
+
+	ctx     context.Context // cancelled when Stop gives up draining, aborting exchange calls
+	abort   context.CancelFunc
+	running sync.WaitGroup // goroutines Stop waits for
+
+	workers []chan job // per-symbol serialized execution, see dispatch
+
+	positionLocks sync.Map // "target:symbol" -> *sync.Mutex, serializes position sync
 }
 
-func NewSignalProcessor(cfg *config.Config, okx, bybit, backpack, lighter models.ExchangeExecutor) *SignalProcessor {
+func NewSignalProcessor(cfg *config.Config, executors map[string]models.ExchangeExecutor) *SignalProcessor {
+	ctx, abort := context.WithCancel(context.Background())
 	return &SignalProcessor{
-		config:           cfg,
-		okxExecutor:      okx,
-		bybitExecutor:    bybit,
-		backpackExecutor: backpack,
-		lighterExecutor:  lighter,
-		riskManager:      risk.NewManager(cfg),
-		stopChan:         make(chan struct{}),
+		config:      cfg,
+		executors:   executors,
+		sources:     make(map[string]models.BalanceProvider),
+		riskManager: risk.NewManager(cfg),
+		stopChan:    make(chan struct{}),
+		ctx:         ctx,
+		abort:       abort,
 	}
 }
 
 func (p *SignalProcessor) Start() error {
-	// Skip if Redis is not available
-	if database.RDB == nil {
-		log.Println("Signal Processor skipped: Redis not configured")
+	// Position sync reads exchanges directly and does not need the stream
+	p.goRun(p.runPositionSync)
+
+	// Skip if there is no queue to consume from
+	if signalQueue == nil {
+		log.Println("Signal Processor skipped: no signal queue available")
 		return nil
 	}
-	log.Println("Signal Processor Started (Redis Stream Consumer)")
-	go p.processSignals()
+	log.Printf("Signal Processor Started (%s)", signalQueue.Name())
+	p.startWorkers()
+	p.goRun(p.processSignals)
+	p.goRun(p.recoverPending)
 	return nil
 }
 
+// goRun runs fn in a goroutine that Stop waits for
+func (p *SignalProcessor) goRun(fn func()) {
+	p.running.Add(1)
+	go func() {
+		defer p.running.Done()
+		fn()
+	}()
+}
+
+// orderTimeout is the deadline for sizing and placing one order on one target
+func (p *SignalProcessor) orderTimeout() time.Duration {
+	if n := p.config.GetSync().OrderTimeout; n > 0 {
+		return time.Duration(n) * time.Second
+	}
+	return 30 * time.Second
+}
+
 func (p *SignalProcessor) processSignals() {
 	ctx := context.Background()
-	groupName := "trading-group"
-	consumerName := "processor-1"
+
+	// Reads are interrupted by Stop; the messages already read are finished
+	readCtx, cancelRead := context.WithCancel(p.ctx)
+	defer cancelRead()
+	go func() {
+		<-p.stopChan
+		cancelRead()
+	}()
 
 	for {
 		select {
 		case <-p.stopChan:
 			return
 		default:
-			// Read new messages
-			entries, err := database.RDB.XReadGroup(ctx, &redis.XReadGroupArgs{
-				Group:    groupName,
-				Consumer: consumerName,
-				Streams:  []string{"signals:trading", ">"},
-				Count:    1,
-				Block:    5 * time.Second,
-			}).Result()
+		}
 
-			if err != nil {
-				if err != redis.Nil {
-					log.Printf("XReadGroup Error: %v", err)
-					time.Sleep(time.Second)
-				}
-				continue
+		msgs, err := signalQueue.Read(readCtx, p.batchSize(), 5*time.Second)
+		if err != nil {
+			if readCtx.Err() != nil {
+				return
 			}
+			log.Printf("Signal Queue Read Error: %v", err)
+			time.Sleep(time.Second)
+			continue
+		}
 
-			for _, stream := range entries {
-				for _, msg := range stream.Messages {
-					p.handleMessage(ctx, msg)
-				}
-			}
+		// Finish the whole batch before reading on, so partitions are only
+		// released by rebalance once nothing from them is in flight
+		var wg sync.WaitGroup
+		for _, msg := range msgs {
+			p.deliver(ctx, msg, &wg)
 		}
-		// Basic logic: also try to process pending messages occasionally or on startup
-		// For this implementation, we will focus on the main loop.
+		wg.Wait()
 	}
 }
 
-func (p *SignalProcessor) handleMessage(ctx context.Context, msg redis.XMessage) {
+func (p *SignalProcessor) handleMessage(ctx context.Context, msg Message) {
 	start := time.Now()
 	defer func() {
 		metrics.OrderLatency.Observe(time.Since(start).Seconds())
 	}()
 
-	payload, ok := msg.Values["payload"].(string)
-	if !ok {
-		log.Printf("Invalid message payload in msg %s", msg.ID)
-		database.RDB.XAck(ctx, "signals:trading", "trading-group", msg.ID)
+	var signal models.TradingSignal
+	if err := json.Unmarshal([]byte(msg.Payload), &signal); err != nil {
+		log.Printf("Failed to unmarshal signal in msg %s: %v", msg.ID, err)
+		signalQueue.Ack(ctx, msg)
 		return
 	}
 
-	var signal models.TradingSignal
-	if err := json.Unmarshal([]byte(payload), &signal); err != nil {
-		log.Printf("Failed to unmarshal signal in msg %s: %v", msg.ID, err)
-		database.RDB.XAck(ctx, "signals:trading", "trading-group", msg.ID)
+	log.Printf("Processing Signal from Queue [%s]: %s %s", msg.ID, signal.Side, signal.Symbol)
+
+	// Route by sync items: only matching rules' targets receive the signal
+	items := MatchSyncItems(p.config.GetSyncItems(), &signal)
+	if len(items) == 0 {
+		log.Printf("Signal %s rejected: no enabled sync item matches source %q symbol %q", msg.ID, signal.Source, signal.Symbol)
+		signalQueue.Ack(ctx, msg)
 		return
 	}
 
-	log.Printf("Processing Signal from Stream [%s]: %s %s", msg.ID, signal.Side, signal.Symbol)
+	// Position-mode items react to the event by re-reading positions instead of copying the fill.
+	// Failures are left to the next periodic pass rather than retried from the stream.
+	items, positionItems := splitSyncItems(items)
+	if signal.Action == "" {
+		for _, item := range positionItems {
+			p.syncItemPositions(item, signal.Symbol)
+		}
+	}
+	if signal.Action == models.ActionPlace {
+		items = orderMirrorItems(items)
+	}
+	if len(items) == 0 {
+		signalQueue.Ack(ctx, msg)
+		return
+	}
 
-	// Keep track of original quantity for idempotency keys
-	originalQuantity := signal.Quantity
+	targets, missing := p.routeTargets(items)
+	for _, id := range missing {
+		log.Printf("Signal %s: target %s is not configured, skipping", msg.ID, id)
+	}
+	if msg.Targets != nil {
+		targets = restrictTargets(targets, msg.Targets)
+	}
 
 	// 1. Risk Check
 	if err := p.riskManager.PreOrderCheck(&signal); err != nil {
 		log.Printf("Risk Check Failed: %v", err)
-		database.RDB.XAck(ctx, "signals:trading", "trading-group", msg.ID)
+		signalQueue.Ack(ctx, msg)
 		return
 	}
 
-	// 2. Calculate Position
-	signal.Quantity = signal.Quantity * p.config.GetSync().PositionRatio
+	// ProduceSignal assigns every signal an ID; one queued before it did is
+	// keyed by its payload, which stays the same when it is published again
+	signalKey := signal.SignalID
+	if signalKey == "" {
+		sum := sha256.Sum256([]byte(msg.Payload))
+		signalKey = "payload-" + hex.EncodeToString(sum[:8])
+	}
+	execKey := executionKey(signal.Source, signalKey)
 
 	// 3. Execute Orders in Parallel
 	var wg sync.WaitGroup
-	var okxErr, bybitErr, backpackErr, lighterErr error
-	wg.Add(4)
-
-	go func() {
-		defer wg.Done()
-
-		// Idempotency Check (OKX)
-		duplicate, err := IsDuplicate(ctx, signal.SignalID, "okx", originalQuantity, signal.Price)
-		if err == nil && duplicate {
-			log.Printf("OKX Duplicate Signal Detected, skipping: %s", signal.SignalID)
-			return
-		}
-
-		res, err := p.okxExecutor.PlaceOrder(&signal)
-		okxErr = err
-		if okxErr == nil {
-			MarkProcessed(ctx, signal.SignalID, "okx", originalQuantity, signal.Price)
-		}
-
-		if res != nil {
-			database.SaveOrderResult(res)
-		}
-		if okxErr != nil {
-			log.Printf("OKX Execution Error: %v", okxErr)
-			metrics.OrdersCounter.WithLabelValues("okx", "failed").Inc()
-		} else {
-			metrics.OrdersCounter.WithLabelValues("okx", "success").Inc()
-		}
-	}()
-
-	go func() {
-		defer wg.Done()
-
-		// Idempotency Check (Bybit)
-		duplicate, err := IsDuplicate(ctx, signal.SignalID, "bybit", originalQuantity, signal.Price)
-		if err == nil && duplicate {
-			log.Printf("Bybit Duplicate Signal Detected, skipping: %s", signal.SignalID)
-			return
-		}
-
-		res, err := p.bybitExecutor.PlaceOrder(&signal)
-		bybitErr = err
-		if bybitErr == nil {
-			MarkProcessed(ctx, signal.SignalID, "bybit", originalQuantity, signal.Price)
-		}
-
-		if res != nil {
-			database.SaveOrderResult(res)
-		}
-		if bybitErr != nil {
-			log.Printf("Bybit Execution Error: %v", bybitErr)
-			metrics.OrdersCounter.WithLabelValues("bybit", "failed").Inc()
-		} else {
-			metrics.OrdersCounter.WithLabelValues("bybit", "success").Inc()
-		}
-	}()
-
-	go func() {
-		defer wg.Done()
-
-		// Skip if Backpack executor is not configured
-		if p.backpackExecutor == nil {
-			return
-		}
-
-		// Idempotency Check (Backpack)
-		duplicate, err := IsDuplicate(ctx, signal.SignalID, "backpack", originalQuantity, signal.Price)
-		if err == nil && duplicate {
-			log.Printf("Backpack Duplicate Signal Detected, skipping: %s", signal.SignalID)
-			return
-		}
+	var mu sync.Mutex
+	failures := make(map[string]error)
+
+	for id, target := range targets {
+		wg.Add(1)
+		go func(id string, target route) {
+			defer wg.Done()
+			executor := target.executor
+			// Exchange calls share one deadline and are aborted if Stop stops
+			// waiting; the queue and state bookkeeping below still completes
+			orderCtx, cancel := context.WithTimeout(p.ctx, p.orderTimeout())
+			defer cancel()
+			state := TargetExecution{Target: id}
+			fail := func(err error) {
+				state.Status, state.Error = ExecutionFailed, err.Error()
+				finishExecution(ctx, execKey, state)
+				metrics.OrdersCounter.WithLabelValues(id, "failed").Inc()
+				mu.Lock()
+				failures[id] = err
+				mu.Unlock()
+			}
 
-		res, err := p.backpackExecutor.PlaceOrder(&signal)
-		backpackErr = err
-		if backpackErr == nil {
-			MarkProcessed(ctx, signal.SignalID, "backpack", originalQuantity, signal.Price)
-		}
+			// Retries and replays only go to targets that have not completed
+			attempt, err := beginExecution(ctx, execKey, id)
+			if err != nil {
+				log.Printf("%s Failed to record execution state for %s: %v", executor.Name(), signalKey, err)
+			} else if attempt == 0 {
+				log.Printf("%s already executed signal %s, skipping", executor.Name(), signalKey)
+				return
+			}
+			state.Attempts = attempt
+
+			// Cancels and amendments act on the copies of an earlier order,
+			// which the order links track; nothing new is reserved
+			if signal.Action == models.ActionCancel || signal.Action == models.ActionAmend {
+				if err := p.applyOrderChange(orderCtx, target.item, id, executor, &signal, signalKey); err != nil {
+					log.Printf("%s %s Error: %v", executor.Name(), signal.Action, err)
+					fail(err)
+					return
+				}
+				state.Status = ExecutionSucceeded
+				finishExecution(ctx, execKey, state)
+				return
+			}
 
-		if res != nil {
-			database.SaveOrderResult(res)
-		}
-		if backpackErr != nil {
-			log.Printf("Backpack Execution Error: %v", backpackErr)
-			metrics.OrdersCounter.WithLabelValues("backpack", "failed").Inc()
-		} else {
-			metrics.OrdersCounter.WithLabelValues("backpack", "success").Inc()
-		}
-	}()
+			// A fill of an order that was copied while resting is already on the target's book
+			if signal.Action == "" && target.item.MirrorsOrders() && signal.SourceOrderID != "" {
+				linked, err := database.HasOrderLink(signal.Source, signal.SourceOrderID, id)
+				if err != nil {
+					fail(fmt.Errorf("failed to check order links: %w", err))
+					return
+				}
+				if linked {
+					log.Printf("%s already has a copy of source order %s, not mirroring fill %s", executor.Name(), signal.SourceOrderID, signalKey)
+					state.Status, state.Error = ExecutionSkipped, "order mirrored while resting"
+					finishExecution(ctx, execKey, state)
+					return
+				}
+			}
 
-	go func() {
-		defer wg.Done()
+			// Idempotency: reserve the signal on this target before placing anything
+			resKey := reservationKey(id, signal.Source, signalKey)
+			clientID := clientOrderID(id, signal.Source, signalKey)
+			existing, reserved, err := Reserve(ctx, resKey, clientID)
+			if err != nil {
+				fail(fmt.Errorf("failed to reserve signal: %w", err))
+				return
+			}
+			if !reserved {
+				if existing.Status == ReservationDone {
+					log.Printf("%s Duplicate Signal Detected, skipping: %s", executor.Name(), signalKey)
+					state.Status = ExecutionSucceeded
+					finishExecution(ctx, execKey, state)
+					return
+				}
+				// An earlier attempt did not finish; the exchange knows whether it placed the order
+				order, err := recoverInFlight(orderCtx, resKey, existing, executor, signal.Symbol)
+				if err != nil {
+					log.Printf("%s In-flight Recovery Error: %v", executor.Name(), err)
+					fail(err)
+					return
+				}
+				if order != nil {
+					log.Printf("%s found in-flight order %s for signal %s", executor.Name(), existing.ClientOrderID, signalKey)
+					metrics.OrdersCounter.WithLabelValues(id, "success").Inc()
+					state.Status, state.OrderID = ExecutionSucceeded, order.OrderID
+					linkOrder(id, &signal, order.OrderID)
+					finishExecution(ctx, execKey, state)
+					return
+				}
+			}
 
-		// Idempotency Check (Lighter)
-		duplicate, err := IsDuplicate(ctx, signal.SignalID, "lighter", originalQuantity, signal.Price)
-		if err == nil && duplicate {
-			log.Printf("Lighter Duplicate Signal Detected, skipping: %s", signal.SignalID)
-			return
-		}
+			// 2. Calculate Position for this target
+			targetSignal := signal
+			targetSignal.ClientOrderID = clientID
+			targetSignal.Quantity, err = p.sizeOrder(orderCtx, target.item, id, executor, &signal)
+			if err != nil {
+				log.Printf("%s Sizing Error: %v", executor.Name(), err)
+				ReleaseReservation(ctx, resKey)
+				fail(err)
+				return
+			}
+			if err := p.protectOrder(orderCtx, executor, &targetSignal); err != nil {
+				log.Printf("%s Protection Error: %v", executor.Name(), err)
+				ReleaseReservation(ctx, resKey)
+				fail(err)
+				return
+			}
 
-		res, err := p.lighterExecutor.PlaceOrder(&signal)
-		lighterErr = err
-		if lighterErr == nil {
-			MarkProcessed(ctx, signal.SignalID, "lighter", originalQuantity, signal.Price)
-		}
+			// A failed placement stays in flight: the order may still have gone
+			// through, so the next attempt checks the exchange before placing again
+			res, err := executor.PlaceOrder(orderCtx, &targetSignal)
+			if err == nil {
+				CompleteReservation(ctx, resKey)
+			}
 
-		if res != nil {
-			database.SaveOrderResult(res)
-		}
-		if lighterErr != nil {
-			log.Printf("Lighter Execution Error: %v", lighterErr)
-			metrics.OrdersCounter.WithLabelValues("lighter", "failed").Inc()
-		} else {
-			metrics.OrdersCounter.WithLabelValues("lighter", "success").Inc()
-		}
-	}()
+			if res != nil {
+				res.Exchange = id // record the account, not just the venue
+				database.SaveOrderResult(res)
+				state.OrderID = res.OrderID
+				if err == nil {
+					linkOrder(id, &targetSignal, res.OrderID)
+				}
+			}
+			if err != nil {
+				log.Printf("%s Execution Error: %v", executor.Name(), err)
+				fail(err)
+			} else if res != nil && res.Status == "skipped" {
+				// Below exchange minimums: retrying would not help
+				log.Printf("%s Order Skipped: %s", executor.Name(), res.ErrorMessage)
+				metrics.OrdersCounter.WithLabelValues(id, "skipped").Inc()
+				state.Status, state.Error = ExecutionSkipped, res.ErrorMessage
+				finishExecution(ctx, execKey, state)
+			} else {
+				metrics.OrdersCounter.WithLabelValues(id, "success").Inc()
+				state.Status = ExecutionSucceeded
+				finishExecution(ctx, execKey, state)
+			}
+		}(id, target)
+	}
 
 	wg.Wait()
 
-	if okxErr == nil && bybitErr == nil && backpackErr == nil && lighterErr == nil {
-		// Success on all exchanges
-		database.RDB.XAck(ctx, "signals:trading", "trading-group", msg.ID)
-		log.Printf("Successfully processed signal %s on all exchanges", msg.ID)
+	if len(failures) == 0 {
+		// Success on all targets
+		signalQueue.Ack(ctx, msg)
+		log.Printf("Successfully processed signal %s on %d target(s)", msg.ID, len(targets))
 	} else {
 		// Failure logic: Retry/DLQ
-		p.handleFailure(ctx, msg)
+		p.handleFailure(ctx, msg, failures)
 	}
 }
 
-func (p *SignalProcessor) handleFailure(ctx context.Context, msg redis.XMessage) {
-	// Use XPending to get delivery count
-	pending, err := database.RDB.XPendingExt(ctx, &redis.XPendingExtArgs{
-		Stream: "signals:trading",
-		Group:  "trading-group",
-		Start:  msg.ID,
-		End:    msg.ID,
-		Count:  1,
-	}).Result()
-
-	deliveryCount := int64(0)
-	if err == nil && len(pending) > 0 {
-		deliveryCount = pending[0].RetryCount
-	}
-
-	if deliveryCount >= 3 {
-		log.Printf("Signal %s failed %d times, moving to DLQ", msg.ID, deliveryCount)
-		database.RDB.XAdd(ctx, &redis.XAddArgs{
-			Stream: "signals:dlq",
-			Values: msg.Values,
-		})
-		database.RDB.XAck(ctx, "signals:trading", "trading-group", msg.ID)
+func (p *SignalProcessor) handleFailure(ctx context.Context, msg Message, failures map[string]error) {
+	if msg.Deliveries > p.maxRetries() {
+		p.moveToDLQ(ctx, msg, failures)
 	} else {
-		log.Printf("Signal %s failed (attempt %d), will be retried by consumer", msg.ID, deliveryCount)
-		// We don't ACK, so it will stay in PEL.
-		// In a real system, we'd have a claimer or use XReadGroup with 0 to re-process.
+		// Left pending; recoverPending delivers it again once it has been idle long enough
+		log.Printf("Signal %s failed (attempt %d), will be retried", msg.ID, msg.Deliveries)
 	}
 }
 
+// Stop stops taking new signals and waits up to drainTimeout for those in
+// progress. Exchange calls still running after that are cancelled; signals
+// that did not finish stay pending and are retried after the restart.
 func (p *SignalProcessor) Stop() {
 	close(p.stopChan)
-	p.okxExecutor.Close()
-	p.bybitExecutor.Close()
-	if p.backpackExecutor != nil {
-		p.backpackExecutor.Close()
+	if !waitTimeout(&p.running, drainTimeout) {
+		log.Printf("Signal Processor: in-flight signals did not finish within %s, aborting", drainTimeout)
+		p.abort()
+		if !waitTimeout(&p.running, abortGrace) {
+			log.Println("Signal Processor: aborted signals are still unwinding, stopping anyway")
+		}
+	}
+	p.abort()
+	if signalQueue != nil {
+		signalQueue.Close(context.Background())
+	}
+	for _, executor := range p.executors {
+		executor.Close()
+	}
+}
+
+// waitTimeout waits for wg and reports whether it finished within timeout
+func waitTimeout(wg *sync.WaitGroup, timeout time.Duration) bool {
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return true
+	case <-time.After(timeout):
+		return false
 	}
-	p.lighterExecutor.Close()
 }
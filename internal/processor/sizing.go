@@ -0,0 +1,130 @@
+package processor
+
+import (
+	"context"
+	"crypto-sync-bot/internal/config"
+	"crypto-sync-bot/internal/models"
+	"fmt"
+	"strings"
+)
+
+// RegisterSource makes a signal source's balance available for equity-proportional sizing
+func (p *SignalProcessor) RegisterSource(source string, provider models.BalanceProvider) {
+	p.sourcesMu.Lock()
+	defer p.sourcesMu.Unlock()
+	p.sources[strings.ToLower(source)] = provider
+}
+
+// sourceBalance looks up a registered source first, then falls back to an
+// executor with the same ID (e.g. a Bybit account that is both lead and target).
+func (p *SignalProcessor) sourceBalance(ctx context.Context, source, asset string) (float64, error) {
+	p.sourcesMu.RLock()
+	provider, ok := p.sources[strings.ToLower(source)]
+	p.sourcesMu.RUnlock()
+	if !ok {
+		executor, found := p.executors[strings.ToLower(source)]
+		if !found {
+			return 0, fmt.Errorf("no balance provider for source %q", source)
+		}
+		provider = executor
+	}
+	return provider.GetBalance(ctx, asset)
+}
+
+// sourcePosition looks up a registered source that reports positions first,
+// then falls back to an executor with the same ID, like sourceBalance
+func (p *SignalProcessor) sourcePosition(ctx context.Context, source, symbol string) (*models.Position, error) {
+	p.sourcesMu.RLock()
+	provider := p.sources[strings.ToLower(source)]
+	p.sourcesMu.RUnlock()
+	if positions, ok := provider.(models.PositionProvider); ok {
+		return positions.GetPosition(ctx, symbol)
+	}
+	executor, found := p.executors[strings.ToLower(source)]
+	if !found {
+		return nil, fmt.Errorf("no position provider for source %q", source)
+	}
+	return executor.GetPosition(ctx, symbol)
+}
+
+// sizeOrder computes the quantity to send to one target according to the sync item's sizing mode
+func (p *SignalProcessor) sizeOrder(ctx context.Context, item config.SyncItem, target string, executor models.ExchangeExecutor, signal *models.TradingSignal) (float64, error) {
+	sizing := item.SizingFor(target)
+
+	switch sizing.Mode {
+	case "", config.SizingRatio:
+		ratio := sizing.Value
+		if ratio <= 0 {
+			ratio = p.config.GetSync().PositionRatio
+		}
+		return signal.Quantity * ratio, nil
+
+	case config.SizingNotional:
+		if signal.Price <= 0 {
+			return 0, fmt.Errorf("notional sizing requires a signal price")
+		}
+		return sizing.Value / signal.Price, nil
+
+	case config.SizingEquity:
+		ratio, err := p.equityRatio(ctx, signal.Source, target, executor, signal.Symbol, sizing.Value)
+		if err != nil {
+			return 0, err
+		}
+		return signal.Quantity * ratio, nil
+
+	case config.SizingFixedQty:
+		return sizing.Value, nil
+
+	default:
+		return 0, fmt.Errorf("unknown sizing mode %q", sizing.Mode)
+	}
+}
+
+// positionRatio returns the factor applied to the source position for one target
+// in position-snapshot sync. Only the proportional sizing modes apply there.
+func (p *SignalProcessor) positionRatio(ctx context.Context, item config.SyncItem, target string, executor models.ExchangeExecutor, symbol string) (float64, error) {
+	sizing := item.SizingFor(target)
+
+	switch sizing.Mode {
+	case "", config.SizingRatio:
+		if sizing.Value > 0 {
+			return sizing.Value, nil
+		}
+		return p.config.GetSync().PositionRatio, nil
+
+	case config.SizingEquity:
+		return p.equityRatio(ctx, item.Source, target, executor, symbol, sizing.Value)
+
+	default:
+		return 0, fmt.Errorf("sizing mode %q is not supported in position sync", sizing.Mode)
+	}
+}
+
+// equityRatio returns target equity / source equity in the symbol's quote asset, times multiplier (default 1)
+func (p *SignalProcessor) equityRatio(ctx context.Context, source, target string, executor models.ExchangeExecutor, symbol string, multiplier float64) (float64, error) {
+	asset := quoteAsset(symbol)
+	sourceEquity, err := p.sourceBalance(ctx, source, asset)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get source balance: %w", err)
+	}
+	if sourceEquity <= 0 {
+		return 0, fmt.Errorf("source %s has no %s equity", source, asset)
+	}
+	targetEquity, err := executor.GetBalance(ctx, asset)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get %s balance: %w", target, err)
+	}
+	if multiplier <= 0 {
+		multiplier = 1
+	}
+	return targetEquity / sourceEquity * multiplier, nil
+}
+
+// quoteAsset returns the settlement asset of a symbol such as BTC-USDT
+func quoteAsset(symbol string) string {
+	parsed, err := models.ParseSymbol(symbol)
+	if err != nil {
+		return "USDT"
+	}
+	return parsed.Quote
+}
@@ -0,0 +1,71 @@
+package processor
+
+import (
+	"context"
+	"crypto-sync-bot/internal/config"
+	"crypto-sync-bot/internal/models"
+	"math"
+	"testing"
+)
+
+// fixedBalance is a signal source reporting a constant equity
+type fixedBalance float64
+
+func (b fixedBalance) GetBalance(ctx context.Context, asset string) (float64, error) {
+	return float64(b), nil
+}
+
+func TestSizeOrder(t *testing.T) {
+	tests := []struct {
+		name         string
+		sizing       config.SizingConfig
+		targetSizing map[string]config.SizingConfig
+		price        float64
+
+		want    float64
+		wantErr bool
+	}{
+		{name: "default ratio from sync config", want: 0.4},
+		{name: "ratio", sizing: config.SizingConfig{Mode: config.SizingRatio, Value: 0.5}, want: 1},
+		{name: "ratio without value falls back", sizing: config.SizingConfig{Mode: config.SizingRatio}, want: 0.4},
+		{name: "notional", sizing: config.SizingConfig{Mode: config.SizingNotional, Value: 500}, price: 250, want: 2},
+		{name: "notional without price", sizing: config.SizingConfig{Mode: config.SizingNotional, Value: 500}, wantErr: true},
+		// target 3000 / source 10000 equity
+		{name: "equity", sizing: config.SizingConfig{Mode: config.SizingEquity}, want: 0.6},
+		{name: "equity with multiplier", sizing: config.SizingConfig{Mode: config.SizingEquity, Value: 2}, want: 1.2},
+		{name: "fixed quantity", sizing: config.SizingConfig{Mode: config.SizingFixedQty, Value: 0.01}, want: 0.01},
+		{
+			name:         "target override",
+			sizing:       config.SizingConfig{Mode: config.SizingFixedQty, Value: 0.01},
+			targetSizing: map[string]config.SizingConfig{"target": {Mode: config.SizingRatio, Value: 2}},
+			want:         4,
+		},
+		{name: "unknown mode", sizing: config.SizingConfig{Mode: "kelly"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exec := newFakeExecutor("target")
+			exec.balance = 3000
+			p := NewSignalProcessor(&config.Config{Sync: config.SyncConfig{PositionRatio: 0.2}},
+				map[string]models.ExchangeExecutor{"target": exec})
+			p.RegisterSource("lead", fixedBalance(10000))
+
+			item := config.SyncItem{Source: "lead", Sizing: tt.sizing, TargetSizing: tt.targetSizing}
+			signal := &models.TradingSignal{Symbol: "BTC-USDT", Quantity: 2, Price: tt.price, Source: "lead"}
+			got, err := p.sizeOrder(context.Background(), item, "target", exec, signal)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("sizeOrder = %v, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("sizeOrder: %v", err)
+			}
+			if math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("sizeOrder = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,196 @@
+package processor
+
+import (
+	"context"
+	"crypto-sync-bot/internal/database"
+	"encoding/json"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// StateStore keeps the idempotency reservations and per-target execution
+// state of signals, next to the signal queue
+type StateStore interface {
+	// Reserve claims key for clientOrderID, or returns the live reservation holding it
+	Reserve(ctx context.Context, key, clientOrderID string) (*Reservation, bool, error)
+	// TakeOver renews an in-flight reservation if it still has the observed reservation time
+	TakeOver(ctx context.Context, key string, observed int64) (bool, error)
+	CompleteReservation(ctx context.Context, key string) error
+	ReleaseReservation(ctx context.Context, key string) error
+
+	// BeginExecution marks target pending for another attempt and returns the
+	// attempt number, or 0 when the target already completed
+	BeginExecution(ctx context.Context, key, target string) (int, error)
+	FinishExecution(ctx context.Context, key string, state TargetExecution) error
+	Executions(ctx context.Context, key string) ([]TargetExecution, error)
+}
+
+// redisState keeps the state in Redis hashes, shared by every replica
+type redisState struct{}
+
+// reserve returns {} when the key was reserved, otherwise the existing
+// status, client order ID and reservation time
+var reserve = redis.NewScript(`
+if redis.call("HSETNX", KEYS[1], "status", "in_flight") == 1 then
+	redis.call("HSET", KEYS[1], "client_order_id", ARGV[1], "reserved_at", ARGV[2])
+	redis.call("PEXPIRE", KEYS[1], ARGV[3])
+	return {}
+end
+return redis.call("HMGET", KEYS[1], "status", "client_order_id", "reserved_at")
+`)
+
+// takeOver renews an in-flight reservation only if nobody else renewed it since
+// it was observed, so one consumer at a time retries an unknown placement
+var takeOver = redis.NewScript(`
+if redis.call("HGET", KEYS[1], "status") == "in_flight" and redis.call("HGET", KEYS[1], "reserved_at") == ARGV[1] then
+	redis.call("HSET", KEYS[1], "reserved_at", ARGV[2])
+	return 1
+end
+return 0
+`)
+
+// claimExecution marks target pending for another attempt unless it already
+// succeeded or was skipped. It returns the attempt number, or 0 when the
+// target is done and must not be sent the signal again.
+var claimExecution = redis.NewScript(`
+local cur = redis.call("HGET", KEYS[1], ARGV[1])
+local attempts = 0
+if cur then
+	local state = cjson.decode(cur)
+	if state.status == "succeeded" or state.status == "skipped" then
+		return 0
+	end
+	attempts = state.attempts or 0
+end
+attempts = attempts + 1
+redis.call("HSET", KEYS[1], ARGV[1], cjson.encode({target = ARGV[1], status = "pending", attempts = attempts, updated_at = tonumber(ARGV[2])}))
+redis.call("PEXPIRE", KEYS[1], ARGV[3])
+return attempts
+`)
+
+func (redisState) Reserve(ctx context.Context, key, clientOrderID string) (*Reservation, bool, error) {
+	now := time.Now().UnixMilli()
+	vals, err := reserve.Run(ctx, database.RDB, []string{key}, clientOrderID, now, reservationTTL.Milliseconds()).Slice()
+	if err != nil {
+		return nil, false, err
+	}
+	if len(vals) == 0 {
+		return nil, true, nil
+	}
+
+	existing := &Reservation{}
+	existing.Status, _ = vals[0].(string)
+	existing.ClientOrderID, _ = vals[1].(string)
+	if s, ok := vals[2].(string); ok {
+		existing.ReservedAt, _ = strconv.ParseInt(s, 10, 64)
+	}
+	return existing, false, nil
+}
+
+func (redisState) TakeOver(ctx context.Context, key string, observed int64) (bool, error) {
+	took, err := takeOver.Run(ctx, database.RDB, []string{key}, strconv.FormatInt(observed, 10), time.Now().UnixMilli()).Int()
+	return took == 1, err
+}
+
+func (redisState) CompleteReservation(ctx context.Context, key string) error {
+	return database.RDB.HSet(ctx, key, "status", ReservationDone).Err()
+}
+
+func (redisState) ReleaseReservation(ctx context.Context, key string) error {
+	return database.RDB.Del(ctx, key).Err()
+}
+
+func (redisState) BeginExecution(ctx context.Context, key, target string) (int, error) {
+	return claimExecution.Run(ctx, database.RDB, []string{key}, target, time.Now().UnixMilli(), executionTTL.Milliseconds()).Int()
+}
+
+func (redisState) FinishExecution(ctx context.Context, key string, state TargetExecution) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	pipe := database.RDB.TxPipeline()
+	pipe.HSet(ctx, key, state.Target, data)
+	pipe.Expire(ctx, key, executionTTL)
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+func (redisState) Executions(ctx context.Context, key string) ([]TargetExecution, error) {
+	raw, err := database.RDB.HGetAll(ctx, key).Result()
+	if err != nil {
+		return nil, err
+	}
+	return decodeExecutions(raw), nil
+}
+
+// sqliteState keeps the state in the local SQLite database, for the embedded queue
+type sqliteState struct{}
+
+// lastStatePrune is when expired SQLite state was last deleted, in milliseconds
+var lastStatePrune atomic.Int64
+
+func (sqliteState) Reserve(ctx context.Context, key, clientOrderID string) (*Reservation, bool, error) {
+	now := time.Now()
+	if last := lastStatePrune.Load(); now.UnixMilli()-last > time.Hour.Milliseconds() && lastStatePrune.CompareAndSwap(last, now.UnixMilli()) {
+		database.PruneState(now.UnixMilli())
+	}
+
+	row, reserved, err := database.ReserveKey(key, clientOrderID, now.UnixMilli(), now.Add(reservationTTL).UnixMilli())
+	if err != nil || reserved {
+		return nil, reserved, err
+	}
+	return &Reservation{Status: row.Status, ClientOrderID: row.ClientOrderID, ReservedAt: row.ReservedAt}, false, nil
+}
+
+func (sqliteState) TakeOver(ctx context.Context, key string, observed int64) (bool, error) {
+	return database.TakeOverReservation(key, observed, time.Now().UnixMilli())
+}
+
+func (sqliteState) CompleteReservation(ctx context.Context, key string) error {
+	return database.SetReservationStatus(key, ReservationDone)
+}
+
+func (sqliteState) ReleaseReservation(ctx context.Context, key string) error {
+	return database.DeleteReservation(key)
+}
+
+func (sqliteState) BeginExecution(ctx context.Context, key, target string) (int, error) {
+	now := time.Now()
+	attempt := 0
+	err := database.UpdateExecution(key, target, now.UnixMilli(), now.Add(executionTTL).UnixMilli(), func(current string) string {
+		var state TargetExecution
+		if current != "" {
+			json.Unmarshal([]byte(current), &state)
+			if state.Status == ExecutionSucceeded || state.Status == ExecutionSkipped {
+				return ""
+			}
+		}
+		attempt = state.Attempts + 1
+		data, _ := json.Marshal(TargetExecution{Target: target, Status: ExecutionPending, Attempts: attempt, UpdatedAt: now.UnixMilli()})
+		return string(data)
+	})
+	return attempt, err
+}
+
+func (sqliteState) FinishExecution(ctx context.Context, key string, state TargetExecution) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	return database.UpdateExecution(key, state.Target, now.UnixMilli(), now.Add(executionTTL).UnixMilli(), func(string) string {
+		return string(data)
+	})
+}
+
+func (sqliteState) Executions(ctx context.Context, key string) ([]TargetExecution, error) {
+	raw, err := database.ListExecutions(key, time.Now().UnixMilli())
+	if err != nil {
+		return nil, err
+	}
+	return decodeExecutions(raw), nil
+}
@@ -2,31 +2,40 @@ package processor
 
 import (
 	"context"
-	"crypto-sync-bot/internal/database"
 	"crypto-sync-bot/internal/models"
 	"encoding/json"
 	"fmt"
+	"log"
 
-	"github.com/redis/go-redis/v9"
+	"github.com/google/uuid"
 )
 
+// ProduceSignal queues a signal for processing. It is written to the outbox
+// first and then published to the signal queue right away; if publishing
+// fails the outbox relay retries it, so the signal survives a Redis outage.
+// A signal without an ID is given one here, before it is stored, so every
+// publication of it is deduplicated as the same signal.
 func ProduceSignal(ctx context.Context, signal *models.TradingSignal) error {
+	if signal.SignalID == "" {
+		signal.SignalID = uuid.NewString()
+	}
 	data, err := json.Marshal(signal)
 	if err != nil {
 		return fmt.Errorf("failed to marshal signal: %w", err)
 	}
 
-	err = database.RDB.XAdd(ctx, &redis.XAddArgs{
-		Stream: "signals:trading",
-		Values: map[string]interface{}{
-			"payload": string(data),
-			"retry_count": 0,
-		},
-	}).Err()
-
+	outbox, err := SaveToOutbox(string(data))
 	if err != nil {
-		return fmt.Errorf("failed to add signal to stream: %w", err)
+		// Without a database there is nothing to fall back on; publish directly
+		log.Printf("Warning: Failed to save signal to outbox: %v", err)
+		if err := publish(ctx, string(data)); err != nil {
+			return fmt.Errorf("failed to add signal to queue: %w", err)
+		}
+		return nil
 	}
 
+	if err := publishOutbox(ctx, outbox); err != nil {
+		log.Printf("Signal %s saved to outbox, publish deferred: %v", signal.SignalID, err)
+	}
 	return nil
 }
@@ -0,0 +1,42 @@
+package processor
+
+import (
+	"context"
+	"crypto-sync-bot/internal/models"
+	"encoding/json"
+	"testing"
+)
+
+func TestProduceSignalAssignsStableID(t *testing.T) {
+	useSQLite(t)
+	ctx := context.Background()
+
+	signal := &models.TradingSignal{Symbol: "BTC-USDT", Side: "BUY", Quantity: 1, Source: "webhook"}
+	if err := ProduceSignal(ctx, signal); err != nil {
+		t.Fatalf("ProduceSignal: %v", err)
+	}
+	if signal.SignalID == "" {
+		t.Fatal("ProduceSignal left the signal without an ID")
+	}
+
+	msgs, err := signalQueue.Read(ctx, 10, 0)
+	if err != nil || len(msgs) != 1 {
+		t.Fatalf("Read = %v, %v; want the produced signal", msgs, err)
+	}
+	var queued models.TradingSignal
+	if err := json.Unmarshal([]byte(msgs[0].Payload), &queued); err != nil {
+		t.Fatalf("queued payload: %v", err)
+	}
+	if queued.SignalID != signal.SignalID {
+		t.Errorf("queued signal ID %q, want %q", queued.SignalID, signal.SignalID)
+	}
+
+	// A signal with an ID keeps it
+	signal = &models.TradingSignal{Symbol: "BTC-USDT", SignalID: "tv-42", Source: "webhook"}
+	if err := ProduceSignal(ctx, signal); err != nil {
+		t.Fatalf("ProduceSignal: %v", err)
+	}
+	if signal.SignalID != "tv-42" {
+		t.Errorf("signal ID changed to %q", signal.SignalID)
+	}
+}